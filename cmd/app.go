@@ -9,11 +9,17 @@ package main
 //
 // )
 //
+//	type Nested struct {
+//		NestedField string `json:"nestedField" gremlin:"nestedField"`
+//	}
+//
 //	type VertexTesting struct {
 //		types.Vertex
-//		TestString string   `json:"testString" gremlin:"testString"`
-//		TestInt    int      `json:"testInt"    gremlin:"testInt"`
-//		TestList   []string `json:"testList"   gremlin:"testList"`
+//		TestString string            `json:"testString" gremlin:"testString"`
+//		TestInt    int               `json:"testInt"    gremlin:"testInt"`
+//		TestList   []string          `json:"testList"   gremlin:"testList"`
+//		MapField   map[string]string `json:"mapField"   gremlin:"mapField"`
+//		Nest       Nested            `json:"nest"       gremlin:"nest"`
 //	}
 func main() {
 }
@@ -30,10 +36,10 @@ func main() {
 // 		TestString: "test",
 // 		TestInt:    1,
 // 		TestList:   []string{"otherField1", "otherField2"},
-// 		// MapField:    map[string]string{"mapField1": "mapField1", "mapField2": "mapField2"},
-// 		// Nest: Nested{
-// 		// 	NestedField: "nested",
-// 		// },
+// 		MapField:   map[string]string{"mapField1": "mapField1", "mapField2": "mapField2"},
+// 		Nest: Nested{
+// 			NestedField: "nested",
+// 		},
 // 	}
 //
 // 	err = driver.Create(db, &test1)
@@ -3,13 +3,23 @@ package comparator
 type Comparator string
 
 const (
-	EQ       Comparator = "="
-	NEQ      Comparator = "!="
-	GT       Comparator = ">"
-	GTE      Comparator = ">="
-	LT       Comparator = "<"
-	LTE      Comparator = "<="
-	IN       Comparator = "in"
-	CONTAINS Comparator = "contains"
-	WITHOUT  Comparator = "without"
+	EQ              Comparator = "="
+	NEQ             Comparator = "!="
+	GT              Comparator = ">"
+	GTE             Comparator = ">="
+	LT              Comparator = "<"
+	LTE             Comparator = "<="
+	IN              Comparator = "in"
+	CONTAINS        Comparator = "contains"
+	WITHOUT         Comparator = "without"
+	BETWEEN         Comparator = "between"
+	INSIDE          Comparator = "inside"
+	OUTSIDE         Comparator = "outside"
+	STARTS_WITH     Comparator = "starts_with"
+	ENDS_WITH       Comparator = "ends_with"
+	NOT_CONTAINS    Comparator = "not_contains"
+	NOT_STARTS_WITH Comparator = "not_starts_with"
+	REGEX           Comparator = "regex"
+	EXISTS          Comparator = "exists"
+	NOT_EXISTS      Comparator = "not_exists"
 )
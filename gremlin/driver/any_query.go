@@ -0,0 +1,278 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// labelKey is the key of the vertex label in the results of ToMapTraversal
+const labelKey = "label"
+
+// anyModel is a model of an AnyQuery, results with its label are loaded into a new value of its type
+type anyModel struct {
+	rt reflect.Type
+	// pointer is set when the model was passed as a pointer, its results are returned as pointers too
+	pointer bool
+	// softDelete is set when the model embeds SoftDelete, its soft deleted vertices are skipped unless unscoped
+	softDelete bool
+}
+
+// AnyQuery is a query over the vertices of several models, e.g. every Dog and Cat, see ModelAny
+type AnyQuery struct {
+	db         *GremlinDriver
+	models     map[string]anyModel
+	conditions []*QueryCondition
+	limit      *int
+	offset     *int
+	orderBy    []*OrderCondition
+	// fields holds the gremlin tags of every model, conditions and order keys may use the tags of any of them
+	fields   map[string]struct{}
+	unscoped bool
+	errs     []error
+}
+
+// ModelAny returns a query matching the vertices of all the models in one traversal
+// e.g. ModelAny(db, Dog{}, Cat{}) finds dogs and cats, the label of each result decides which model it is loaded into
+// the results are values of the model types, or pointers for models passed as pointers
+// the models must have distinct labels, on a driver opened WithRegistry they must be registered
+func ModelAny(db *GremlinDriver, models ...gsmtypes.VertexType) *AnyQuery {
+	q := &AnyQuery{
+		db:         db,
+		models:     make(map[string]anyModel, len(models)),
+		conditions: make([]*QueryCondition, 0),
+		fields:     make(map[string]struct{}),
+		errs:       make([]error, 0),
+	}
+	if len(models) == 0 {
+		q.errs = append(q.errs, errors.New("ModelAny requires at least one model"))
+	}
+	for _, model := range models {
+		if err := q.addModel(model); err != nil {
+			q.errs = append(q.errs, err)
+		}
+	}
+	return q
+}
+
+// addModel resolves the label of the model and adds it to the query
+func (q *AnyQuery) addModel(model gsmtypes.VertexType) error {
+	if model == nil {
+		return errors.New("model cannot be nil")
+	}
+	rt := reflect.TypeOf(model)
+	pointer := rt.Kind() == reflect.Pointer
+	if pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct || !schemaOf(rt).embedded[reflect.TypeFor[gsmtypes.Vertex]()] {
+		return fmt.Errorf("model %s must be a struct embedding types.Vertex", rt)
+	}
+	label := getLabelFromVertex(model)
+	if q.db.registry != nil {
+		info, ok := q.db.registry.modelOf(rt)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrModelNotRegistered, rt)
+		}
+		label = info.Label
+	}
+	if other, ok := q.models[label]; ok && other.rt != rt {
+		return fmt.Errorf("%w: %s of %s is the label of %s", ErrLabelCollision, label, rt, other.rt)
+	}
+	q.models[label] = anyModel{rt: rt, pointer: pointer, softDelete: embedsSoftDelete(rt)}
+	maps.Copy(q.fields, gremlinFieldNames(rt))
+	return nil
+}
+
+// Where adds a condition to the query, the field must be a gremlin tag of at least one of the models
+func (q *AnyQuery) Where(field string, operator comparator.Comparator, value any) *AnyQuery {
+	return q.addCondition(Cond(field, operator, value))
+}
+
+// WhereTraversal adds a custom Gremlin traversal condition
+func (q *AnyQuery) WhereTraversal(traversal *gremlingo.GraphTraversal) *AnyQuery {
+	return q.addCondition(&QueryCondition{traversal: traversal})
+}
+
+// Or adds a condition group which matches when any of the conditions match
+func (q *AnyQuery) Or(conditions ...*QueryCondition) *AnyQuery {
+	return q.addCondition(Or(conditions...))
+}
+
+// And adds a condition group which matches when all of the conditions match
+func (q *AnyQuery) And(conditions ...*QueryCondition) *AnyQuery {
+	return q.addCondition(And(conditions...))
+}
+
+// Not adds a condition which matches when the given condition does not match
+func (q *AnyQuery) Not(condition *QueryCondition) *AnyQuery {
+	return q.addCondition(Not(condition))
+}
+
+// addCondition validates the condition against the gremlin tags of the models and adds it to the query
+func (q *AnyQuery) addCondition(condition *QueryCondition) *AnyQuery {
+	if err := validateCondition(condition, q.fields); err != nil {
+		q.errs = append(q.errs, err)
+	}
+	q.conditions = append(q.conditions, condition)
+	return q
+}
+
+// WithContext binds the query to ctx, see GremlinDriver.WithContext
+func (q *AnyQuery) WithContext(ctx context.Context) *AnyQuery {
+	q.db = q.db.WithContext(ctx)
+	return q
+}
+
+// Unscoped includes the soft deleted vertices of models embedding SoftDelete
+func (q *AnyQuery) Unscoped() *AnyQuery {
+	q.unscoped = true
+	return q
+}
+
+// Limit sets the maximum number of results
+func (q *AnyQuery) Limit(limit int) *AnyQuery {
+	q.limit = &limit
+	return q
+}
+
+// Offset sets the number of results to skip
+func (q *AnyQuery) Offset(offset int) *AnyQuery {
+	q.offset = &offset
+	return q
+}
+
+// OrderBy adds an order key to the query, calling it again adds a secondary key
+// ordering by "id" orders by the element id
+func (q *AnyQuery) OrderBy(field string, order GremlinOrder) *AnyQuery {
+	if err := validateOrderField(field, q.fields); err != nil {
+		q.errs = append(q.errs, err)
+	}
+	q.orderBy = append(q.orderBy, &OrderCondition{field: field, order: order})
+	return q
+}
+
+// Find executes the query and returns all matching results loaded into their model
+func (q *AnyQuery) Find() ([]gsmtypes.VertexType, error) {
+	query, err := q.build()
+	if err != nil {
+		return nil, err
+	}
+	queryResults, err := await(q.db, ToMapTraversal(query, nil, true).ToList)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]gsmtypes.VertexType, 0, len(queryResults))
+	for _, result := range queryResults {
+		v, unloadErr := q.unload(result.GetInterface())
+		if unloadErr != nil {
+			return nil, unloadErr
+		}
+		results = append(results, v)
+	}
+	return results, nil
+}
+
+// Take executes the query and returns the first result loaded into its model
+func (q *AnyQuery) Take() (gsmtypes.VertexType, error) {
+	query, err := q.build()
+	if err != nil {
+		return nil, err
+	}
+	result, err := await(q.db, ToMapTraversal(query, nil, true).Next)
+	if err != nil {
+		return nil, err
+	}
+	return q.unload(result.GetInterface())
+}
+
+// Count returns the number of matching results
+func (q *AnyQuery) Count() (int, error) {
+	query, err := q.build()
+	if err != nil {
+		return 0, err
+	}
+	result, err := await(q.db, query.Count().Next)
+	if err != nil {
+		return 0, err
+	}
+	return result.GetInt()
+}
+
+// unload loads a result into a new value of the model with its label and calls its AfterFind hook
+func (q *AnyQuery) unload(result any) (gsmtypes.VertexType, error) {
+	stringMap, err := toStringMap(result)
+	if err != nil {
+		return nil, err
+	}
+	label, _ := stringMap[labelKey].(string)
+	model, ok := q.models[label]
+	if !ok {
+		return nil, fmt.Errorf("no model for label %q", label)
+	}
+	v := reflect.New(model.rt)
+	if err = recursivelyUnloadIntoStruct(v.Interface(), stringMap); err != nil {
+		return nil, err
+	}
+	if err = afterFind(v.Interface()); err != nil {
+		return nil, err
+	}
+	if !model.pointer {
+		v = v.Elem()
+	}
+	vertex, ok := v.Interface().(gsmtypes.VertexType)
+	if !ok {
+		return nil, fmt.Errorf("model %s must implement types.VertexType", model.rt)
+	}
+	return vertex, nil
+}
+
+// build constructs the Gremlin traversal matching the labels of every model
+// soft deleted vertices are skipped only for the labels of models embedding SoftDelete unless the query is unscoped
+// nothing is built when problems were recorded while the query was built, they are returned as a ValidationError
+func (q *AnyQuery) build() (*gremlingo.GraphTraversal, error) {
+	if len(q.errs) > 0 {
+		return nil, newValidationError(errors.Join(q.errs...))
+	}
+	labels := make([]any, 0, len(q.models))
+	softLabels := make([]any, 0, len(q.models))
+	otherLabels := make([]any, 0, len(q.models))
+	for _, label := range slices.Sorted(maps.Keys(q.models)) {
+		labels = append(labels, label)
+		if q.models[label].softDelete && !q.unscoped {
+			softLabels = append(softLabels, label)
+		} else {
+			otherLabels = append(otherLabels, label)
+		}
+	}
+	var query *gremlingo.GraphTraversal
+	switch {
+	case len(softLabels) == 0:
+		query = q.db.g.V().HasLabel(labels...)
+	case len(otherLabels) == 0:
+		query = q.db.g.V().HasLabel(labels...).HasNot(gsmtypes.DeletedAt)
+	default:
+		query = q.db.g.V().Or(
+			anonymousTraversal.HasLabel(softLabels...).HasNot(gsmtypes.DeletedAt),
+			anonymousTraversal.HasLabel(otherLabels...),
+		)
+	}
+	if err := addQueryConditions(query, q.conditions); err != nil {
+		return nil, newValidationError(err)
+	}
+	addOrderCondition(query, q.orderBy)
+	if q.offset != nil {
+		query = query.Skip(*q.offset)
+	}
+	if q.limit != nil {
+		query = query.Limit(*q.limit)
+	}
+	return query, nil
+}
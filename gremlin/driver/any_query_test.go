@@ -0,0 +1,190 @@
+package driver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testDog struct {
+	gsmtypes.Vertex
+	Name  string `json:"name"  gremlin:"name"`
+	Breed string `json:"breed" gremlin:"breed"`
+}
+
+type testCat struct {
+	gsmtypes.Vertex
+	Name  string `json:"name"  gremlin:"name"`
+	Lives int    `json:"lives" gremlin:"lives"`
+}
+
+func TestAnyQueryBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestTraversal", func(t *testing.T) {
+			t.Parallel()
+			query, err := ModelAny(db, testDog{}, &testCat{}).
+				Where("name", comparator.EQ, "a").
+				Where("lives", comparator.GT, 3).
+				OrderBy("name", Asc).
+				Limit(2).
+				build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "g.V().hasLabel('test_cat','test_dog').has('name','a').has('lives',gt(3))" +
+				".order().by('name',asc).limit(2)"
+			if got := translate(t, query); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		},
+	)
+	t.Run(
+		"TestSoftDelete", func(t *testing.T) {
+			t.Parallel()
+			tests := map[string]struct {
+				query    *AnyQuery
+				expected string
+			}{
+				"TestAllSoftDelete": {
+					ModelAny(db, testArchived{}),
+					"g.V().hasLabel('test_archived').hasNot('deleted_at')",
+				},
+				"TestMixed": {
+					ModelAny(db, testArchived{}, testTombstone{}, testDog{}),
+					"g.V().or(hasLabel('test_archived').hasNot('deleted_at')," +
+						"hasLabel('test_dog','test_tombstone'))",
+				},
+				"TestUnscoped": {
+					ModelAny(db, testArchived{}, testTombstone{}).Unscoped(),
+					"g.V().hasLabel('test_archived','test_tombstone')",
+				},
+			}
+			for name, tt := range tests {
+				query, err := tt.query.build()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := translate(t, query); got != tt.expected {
+					t.Errorf("%s: expected %s, got %s", name, tt.expected, got)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestUnloadDispatchesOnLabel", func(t *testing.T) {
+			t.Parallel()
+			query := ModelAny(db, testDog{}, &testCat{})
+			dog, err := query.unload(map[any]any{"id": int64(1), "label": "test_dog", "name": "rex", "breed": "pug"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := dog.(testDog); !ok || got.Name != "rex" || got.Breed != "pug" || got.ID != int64(1) {
+				t.Errorf("Expected a testDog value, got %#v", dog)
+			}
+			cat, err := query.unload(map[any]any{"label": "test_cat", "name": "tom", "lives": int64(9)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := cat.(*testCat); !ok || got.Name != "tom" || got.Lives != 9 {
+				t.Errorf("Expected a *testCat, got %#v", cat)
+			}
+			if _, err = query.unload(map[any]any{"label": "test_bird"}); err == nil {
+				t.Error("Expected error for a label without a model")
+			}
+		},
+	)
+	t.Run(
+		"TestValidation", func(t *testing.T) {
+			t.Parallel()
+			registry := NewRegistry()
+			if err := registry.Register(testDog{}); err != nil {
+				t.Fatal(err)
+			}
+			registered := newOfflineDriver()
+			registered.registry = registry
+			tests := map[string]struct {
+				query *AnyQuery
+				is    error
+				text  string
+			}{
+				"TestNoModels":      {ModelAny(db), nil, "at least one model"},
+				"TestUnknownField":  {ModelAny(db, testDog{}).Where("lives", comparator.EQ, 1), nil, "lives"},
+				"TestCollision":     {ModelAny(db, testArticle{}, testArticleDraft{}), ErrLabelCollision, ""},
+				"TestNotRegistered": {ModelAny(registered, testDog{}, testCat{}), ErrModelNotRegistered, ""},
+			}
+			for name, tt := range tests {
+				var validationErr *ValidationError
+				_, err := tt.query.Find()
+				if !errors.As(err, &validationErr) || (tt.is != nil && !errors.Is(err, tt.is)) ||
+					!strings.Contains(err.Error(), tt.text) {
+					t.Errorf("%s: expected a ValidationError, got %v", name, err)
+				}
+			}
+		},
+	)
+}
+
+func TestAnyQuery(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+
+	if err = Create(db, &testDog{Name: "rex", Breed: "pug"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = Create(db, &testCat{Name: "tom", Lives: 9}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := ModelAny(db, testDog{}, testCat{}).OrderBy("name", Asc).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if dog, ok := results[0].(testDog); !ok || dog.Name != "rex" || dog.Breed != "pug" {
+		t.Errorf("Expected the dog first, got %#v", results[0])
+	}
+	if cat, ok := results[1].(testCat); !ok || cat.Lives != 9 {
+		t.Errorf("Expected the cat second, got %#v", results[1])
+	}
+	count, err := ModelAny(db, testDog{}, testCat{}).Where("name", comparator.EQ, "tom").Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 result, got %d", count)
+	}
+
+	if err = Create(db, &testArchived{Name: "gone"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = Model[testArchived](db).Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if err = Create(db, &testTombstone{DeletedAt: "yesterday"}); err != nil {
+		t.Fatal(err)
+	}
+	count, err = ModelAny(db, testArchived{}, testTombstone{}).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected only the tombstone, got %d results", count)
+	}
+	count, err = ModelAny(db, testArchived{}, testTombstone{}).Unscoped().Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 results when unscoped, got %d", count)
+	}
+}
@@ -0,0 +1,237 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// defaultBatchSize is the number of vertices written per traversal when no batch size is configured
+const defaultBatchSize = 100
+
+// states of the vertex updated by a batch item, see checkStored
+const (
+	storedFound     = "found"
+	storedStale     = "stale"
+	storedMissing   = "missing"
+	storedDuplicate = "duplicate"
+)
+
+// batchItem is a single vertex of a batch ready to be merged
+type batchItem struct {
+	index int
+	id    any
+	merge *vertexMerge
+	// after calls the after hooks of the value once it was written
+	after func(value any) error
+}
+
+// CreateMany creates all values with one traversal per batch, see WithBatchSize
+// values with fields tagged unique are merged on their natural keys like Upsert
+// the ids and timestamps of the stored vertices are written back into each value
+// values which already have an id or fail are reported in a BatchError, every other value is created
+func CreateMany[T gsmtypes.VertexType](db *GremlinDriver, values []*T) error {
+	return writeMany(db, values, false)
+}
+
+// SaveMany creates the values without an id and updates the ones with an id using one traversal per batch
+// failed values are reported in a BatchError, every other value is written
+func SaveMany[T gsmtypes.VertexType](db *GremlinDriver, values []*T) error {
+	return writeMany(db, values, true)
+}
+
+func writeMany[T gsmtypes.VertexType](db *GremlinDriver, values []*T, allowUpdate bool) error {
+	cardinalities, cardinalityErr := schemaOf(reflect.TypeFor[T]()).cardinalities(db.dbDriver)
+	if cardinalityErr != nil {
+		return cardinalityErr
+	}
+	batchErr := &BatchError{Total: len(values), Errs: make(map[int]error)}
+	now := time.Now().UTC()
+	items := make([]*batchItem, 0, len(values))
+	for i, value := range values {
+		item, err := newBatchItem(db, i, value, allowUpdate, cardinalities, now)
+		if err != nil {
+			batchErr.Errs[i] = err
+			continue
+		}
+		items = append(items, item)
+	}
+
+	batchSize := db.batchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	for start := 0; start < len(items); start += batchSize {
+		batch := checkStored(db, items[start:min(start+batchSize, len(items))], batchErr)
+		if len(batch) == 0 {
+			continue
+		}
+		stored, err := mergeBatch(db, batch)
+		if err != nil {
+			db.logger.Errorf("Batch write failed: %v", err)
+			for _, item := range batch {
+				batchErr.Errs[item.index] = err
+			}
+			continue
+		}
+		for i, item := range batch {
+			value := values[item.index]
+			if err = setStoredVertex(reflect.ValueOf(value).Elem(), stored[i], now); err == nil {
+				if item.merge.guard != nil {
+					item.merge.guard.commit()
+				}
+				err = item.after(value)
+			}
+			if err != nil {
+				batchErr.Errs[item.index] = err
+			}
+		}
+	}
+
+	if len(batchErr.Errs) > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+func newBatchItem[T gsmtypes.VertexType](
+	db *GremlinDriver,
+	index int,
+	value *T,
+	allowUpdate bool,
+	cardinalities map[string]any,
+	now time.Time,
+) (*batchItem, error) {
+	if err := validateStructPointerWithAnonymousVertex(value); err != nil {
+		return nil, err
+	}
+	before, after := beforeCreate, afterCreate
+	if (*value).GetVertexID() != nil {
+		if !allowUpdate {
+			return nil, errors.New("value already has an id, use SaveMany to update it")
+		}
+		before, after = beforeUpdate, afterUpdate
+	}
+	if err := before(value); err != nil {
+		return nil, err
+	}
+	label, properties, err := structToMap(value)
+	if err != nil {
+		return nil, err
+	}
+	id := properties["id"]
+	delete(properties, "id")
+	merge, err := newVertexMerge(id, label, properties, uniqueFieldNames(reflect.TypeFor[T]()), cardinalities, now)
+	if err != nil {
+		return nil, err
+	}
+	if id != nil {
+		if merge.guard, err = newVersionGuard(db, reflect.ValueOf(value).Elem()); err != nil {
+			return nil, err
+		}
+		merge.prefixes = clearedPrefixes(reflect.TypeFor[T](), properties)
+	}
+	return &batchItem{index: index, id: id, merge: merge, after: after}, nil
+}
+
+// checkStored reports the items with an id whose vertex does not exist or fails its version check in batchErr
+// and returns the other items, the vertices are checked before the batch is written so a stale id or version
+// only fails its own item
+func checkStored(db *GremlinDriver, batch []*batchItem, batchErr *BatchError) []*batchItem {
+	keys := make([]any, 0)
+	updates := make([]*batchItem, 0)
+	for _, item := range batch {
+		if item.id != nil {
+			keys = append(keys, "v"+strconv.Itoa(len(keys)))
+			updates = append(updates, item)
+		}
+	}
+	if len(updates) == 0 {
+		return batch
+	}
+	query := db.g.Inject(0).Project(keys...)
+	for _, item := range updates {
+		query = query.By(item.merge.storedState())
+	}
+	result, err := await(db, query.Next)
+	var states map[any]any
+	if err == nil {
+		var ok bool
+		if states, ok = result.GetInterface().(map[any]any); !ok {
+			err = fmt.Errorf("unexpected stored check result %T", result.GetInterface())
+		}
+	}
+	if err != nil {
+		for _, item := range batch {
+			batchErr.Errs[item.index] = err
+		}
+		return nil
+	}
+	missing := make(map[int]bool, len(updates))
+	for i, item := range updates {
+		switch states[keys[i]] {
+		case storedFound:
+			continue
+		case storedStale:
+			batchErr.Errs[item.index] = item.merge.guard.notApplied(item.merge.label, item.id)
+		case storedDuplicate:
+			batchErr.Errs[item.index] = newDuplicateKeyError(item.merge.label, item.id, item.merge.keys)
+		default:
+			batchErr.Errs[item.index] = fmt.Errorf("%s vertex %v not found", item.merge.label, item.id)
+		}
+		missing[item.index] = true
+	}
+	checked := make([]*batchItem, 0, len(batch))
+	for _, item := range batch {
+		if !missing[item.index] {
+			checked = append(checked, item)
+		}
+	}
+	return checked
+}
+
+// mergeBatch writes the batch and returns the id and created_at of each stored vertex in the order of the batch
+func mergeBatch(db *GremlinDriver, batch []*batchItem) ([]map[any]any, error) {
+	query, keys := batchTraversal(db, batch)
+	result, err := await(db, query.Next)
+	if err != nil {
+		return nil, err
+	}
+	selected, ok := result.GetInterface().(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected batch result %T", result.GetInterface())
+	}
+	if len(keys) == 1 {
+		return []map[any]any{selected}, nil
+	}
+	stored := make([]map[any]any, 0, len(keys))
+	for _, key := range keys {
+		element, found := selected[key].(map[any]any)
+		if !found {
+			return nil, fmt.Errorf("batch result is missing the vertex of %s", key)
+		}
+		stored = append(stored, element)
+	}
+	return stored, nil
+}
+
+// batchTraversal chains a mergeV or addV step per item, each step labels its vertex with its position
+// so a single select returns all of them, a batch of one item returns its element map directly
+func batchTraversal(db *GremlinDriver, batch []*batchItem) (*gremlingo.GraphTraversal, []any) {
+	var query *gremlingo.GraphTraversal
+	keys := make([]any, 0, len(batch))
+	for i, item := range batch {
+		key := "v" + strconv.Itoa(i)
+		query = item.merge.traversal(db, query).As(key)
+		keys = append(keys, key)
+	}
+	if len(keys) == 1 {
+		return query.ElementMap(gsmtypes.CreatedAt), keys
+	}
+	return query.Select(keys...).By(anonymousTraversal.ElementMap(gsmtypes.CreatedAt)), keys
+}
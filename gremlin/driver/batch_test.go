@@ -0,0 +1,204 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+)
+
+func TestBatchValidation(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestBatchTraversal", func(t *testing.T) {
+			t.Parallel()
+			now := time.Now().UTC()
+			first, err := newBatchItem(db, 0, &testVertex{Name: "same"}, false, nil, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := newBatchItem(db, 1, &testVertex{Name: "same"}, false, nil, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, keys := batchTraversal(db, []*batchItem{first, second})
+			if len(keys) != 2 {
+				t.Fatalf("Expected 2 keys, got %d", len(keys))
+			}
+			got := translate(t, query)
+			// values without an id or natural keys are added so equal values are not merged into one vertex
+			if !strings.HasPrefix(got, "g.addV('test_vertex')") || strings.Count(got, "addV(") != 2 ||
+				strings.Contains(got, "mergeV(") {
+				t.Errorf("Expected two chained addV steps, got %s", got)
+			}
+			if !strings.HasSuffix(got, ".as('v1').select('v0','v1').by(elementMap('created_at'))") {
+				t.Errorf("Expected ids to be selected in order, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestBatchTraversalSingle", func(t *testing.T) {
+			t.Parallel()
+			item, err := newBatchItem(db, 0, &testVertex{Name: "first"}, false, nil, time.Now().UTC())
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, _ := batchTraversal(db, []*batchItem{item})
+			if got := translate(t, query); strings.Contains(got, "select(") ||
+				!strings.HasSuffix(got, ".elementMap('created_at')") {
+				t.Errorf("Expected no select for a single item, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestBatchTraversalUpdate", func(t *testing.T) {
+			t.Parallel()
+			value := &testVertex{Name: "stored"}
+			value.ID = 7
+			item, err := newBatchItem(db, 0, value, true, nil, time.Now().UTC())
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, _ := batchTraversal(db, []*batchItem{item})
+			if got := translate(t, query); !strings.HasPrefix(got, "g.V(7).hasLabel('test_vertex').property(single,") ||
+				strings.Contains(got, "mergeV(") {
+				t.Errorf("Expected the stored vertex to be updated without a mergeV step, got %s", got)
+			}
+			expected := "coalesce(V(7).hasLabel('test_vertex').constant('found'),constant('missing'))"
+			if got := translate(t, item.merge.storedState()); !strings.Contains(got, expected) {
+				t.Errorf("Expected the stored check to look up the vertex, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestBatchErrorPerItem", func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			withID := &testVertex{Name: "second"}
+			withID.ID = 1
+			// the cancelled context keeps the valid item from being sent by the offline driver
+			err := CreateMany(db.WithContext(ctx), []*testVertex{{Name: "first"}, withID, nil})
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) {
+				t.Fatalf("Expected BatchError, got %v", err)
+			}
+			if batchErr.Total != 3 || len(batchErr.Errs) != 3 {
+				t.Fatalf("Expected 3 of 3 failed items, got %v", batchErr)
+			}
+			if !errors.Is(batchErr.Errs[0], ErrQueryAborted) {
+				t.Errorf("Expected item 0 to be aborted, got %v", batchErr.Errs[0])
+			}
+			if !strings.Contains(batchErr.Errs[1].Error(), "already has an id") {
+				t.Errorf("Expected item 1 to be rejected for its id, got %v", batchErr.Errs[1])
+			}
+			if !strings.Contains(batchErr.Errs[2].Error(), "nil") {
+				t.Errorf("Expected item 2 to be rejected as nil, got %v", batchErr.Errs[2])
+			}
+		},
+	)
+}
+
+func TestBatch(t *testing.T) {
+	db, err := Open(DbURL, Gremlin, WithBatchSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+	values := []*testVertex{{Name: "first"}, {Name: "second"}, {Name: "third"}}
+	t.Run(
+		"TestCreateMany", func(t *testing.T) {
+			if err = CreateMany(db, values); err != nil {
+				t.Fatal(err)
+			}
+			for i, value := range values {
+				if value.ID == nil || value.CreatedAt.IsZero() {
+					t.Fatalf("Expected id and created at to be set on item %d", i)
+				}
+				found, findErr := Model[testVertex](db).ID(value.ID)
+				if findErr != nil {
+					t.Fatal(findErr)
+				}
+				if found.Name != value.Name {
+					t.Errorf("Expected id of item %d to belong to %s, got %s", i, value.Name, found.Name)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestCreateManyEqualValues", func(t *testing.T) {
+			equal := []*testVertex{{Name: "equal"}, {Name: "equal"}}
+			if err = CreateMany(db, equal); err != nil {
+				t.Fatal(err)
+			}
+			if equal[0].ID == nil || equal[0].ID == equal[1].ID {
+				t.Fatalf("Expected two distinct ids, got %v and %v", equal[0].ID, equal[1].ID)
+			}
+			count, countErr := Model[testVertex](db).Where("name", comparator.EQ, "equal").Count()
+			if countErr != nil {
+				t.Fatal(countErr)
+			}
+			if count != 2 {
+				t.Errorf("Expected 2 vertices, got %d", count)
+			}
+			// the later subtests count every vertex
+			if err = Model[testVertex](db).Where("name", comparator.EQ, "equal").Delete(); err != nil {
+				t.Fatal(err)
+			}
+		},
+	)
+	t.Run(
+		"TestSaveMany", func(t *testing.T) {
+			values[0].Name = "updated"
+			values = append(values, &testVertex{Name: "fourth"})
+			if err = SaveMany(db, values); err != nil {
+				t.Fatal(err)
+			}
+			count, countErr := Model[testVertex](db).Count()
+			if countErr != nil {
+				t.Fatal(countErr)
+			}
+			if count != 4 {
+				t.Errorf("Expected 4 vertices, got %d", count)
+			}
+			updated, countErr := Model[testVertex](db).Where("name", comparator.EQ, "updated").Count()
+			if countErr != nil {
+				t.Fatal(countErr)
+			}
+			if updated != 1 {
+				t.Errorf("Expected 1 updated vertex, got %d", updated)
+			}
+		},
+	)
+	t.Run(
+		"TestSaveManyMissingID", func(t *testing.T) {
+			stale := &testVertex{Name: "stale"}
+			stale.ID = int64(-1)
+			values[1].Name = "renamed"
+			err = SaveMany(db, []*testVertex{values[1], stale})
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) || len(batchErr.Errs) != 1 || batchErr.Errs[1] == nil {
+				t.Fatalf("Expected only the stale id to fail, got %v", err)
+			}
+			if !strings.Contains(batchErr.Errs[1].Error(), "not found") {
+				t.Errorf("Expected a not found error, got %v", batchErr.Errs[1])
+			}
+			count, countErr := Model[testVertex](db).Count()
+			if countErr != nil {
+				t.Fatal(countErr)
+			}
+			renamed, countErr := Model[testVertex](db).Where("name", comparator.EQ, "renamed").Count()
+			if countErr != nil {
+				t.Fatal(countErr)
+			}
+			if count != 4 || renamed != 1 {
+				t.Errorf("Expected no vertex to be created and one to be renamed, got %d and %d", count, renamed)
+			}
+		},
+	)
+}
@@ -0,0 +1,178 @@
+package driver
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// multiValue is a slice property stored as one vertex property per element
+type multiValue struct {
+	key         string
+	cardinality any
+	values      []any
+}
+
+// fieldCardinality returns the cardinality the property of a field of type rt is written with
+// multi is true when every element of a slice is stored as its own vertex property, slices default to list
+// cardinality, or set on Neptune which has no lists, and are stored as a single list value when tagged single
+func fieldCardinality(dbDriver DatabaseDriver, rt reflect.Type, tag gremlinTag) (any, bool, error) {
+	// byte slices and slices which convert themselves are single values
+	isSlice := rt.Kind() == reflect.Slice && rt.Elem().Kind() != reflect.Uint8 && !convertsItself(rt)
+	switch tag.cardinality {
+	case singleTagOption:
+		return cardinality.Single, false, nil
+	case listTagOption, setTagOption:
+		if !isSlice {
+			return nil, false, fmt.Errorf("%s cardinality requires a slice field, got %s", tag.cardinality, rt)
+		}
+		if tag.cardinality == setTagOption {
+			return cardinality.Set, true, nil
+		}
+		if dbDriver == Neptune {
+			return nil, false, fmt.Errorf("%s does not support list cardinality, use set", Neptune)
+		}
+		return cardinality.List, true, nil
+	}
+	if !isSlice {
+		return cardinality.Single, false, nil
+	}
+	if dbDriver == Neptune {
+		return cardinality.Set, true, nil
+	}
+	return cardinality.List, true, nil
+}
+
+// multiValuedProperties returns the cardinality of every property of rt stored as one vertex property per element
+// keyed by property name, the properties of nested structs are included with their prefix
+func multiValuedProperties(dbDriver DatabaseDriver, rt reflect.Type) (map[string]any, error) {
+	properties := make(map[string]any)
+	err := addMultiValuedProperties(dbDriver, rt, "", properties, map[reflect.Type]bool{})
+	return properties, err
+}
+
+func addMultiValuedProperties(
+	dbDriver DatabaseDriver,
+	rt reflect.Type,
+	prefix string,
+	properties map[string]any,
+	visiting map[reflect.Type]bool,
+) error {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct || visiting[rt] {
+		return nil
+	}
+	visiting[rt] = true
+	defer delete(visiting, rt)
+	schema := schemaOf(rt)
+	if schema.tagsErr != nil {
+		return schema.tagsErr
+	}
+	tags := schema.tags
+	var err error
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if field.Anonymous {
+			if err = addMultiValuedProperties(dbDriver, field.Type, prefix, properties, visiting); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := tags[i]
+		if tag.name == "" || tag.json || tag.readOnly {
+			continue
+		}
+		name := prefix + tag.name
+		if isNestedStruct(field.Type) {
+			if err = addMultiValuedProperties(
+				dbDriver, field.Type, name+nestedFieldSeparator, properties, visiting,
+			); err != nil {
+				return err
+			}
+			continue
+		}
+		fieldCard, multi, err := fieldCardinality(dbDriver, field.Type, tag)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if multi {
+			properties[name] = fieldCard
+		}
+	}
+	return nil
+}
+
+// splitMultiValues removes the multi-valued properties from properties and returns them sorted by key
+func splitMultiValues(properties map[string]any, cardinalities map[string]any) ([]multiValue, error) {
+	multiValues := make([]multiValue, 0)
+	for _, key := range slices.Sorted(maps.Keys(cardinalities)) {
+		value, ok := properties[key]
+		if !ok {
+			continue
+		}
+		delete(properties, key)
+		values, err := propertyValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding property %s: %w", key, err)
+		}
+		multiValues = append(multiValues, multiValue{key: key, cardinality: cardinalities[key], values: values})
+	}
+	return multiValues, nil
+}
+
+// propertyValues converts the elements of a slice the same way single property values are converted
+func propertyValues(value any) ([]any, error) {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return make([]any, 0), nil
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a slice, got %T", value)
+	}
+	values := make([]any, 0, rv.Len())
+	element := make(map[string]any, 1)
+	for i := range rv.Len() {
+		if err := setPropertyValue(element, "", rv.Index(i)); err != nil {
+			return nil, err
+		}
+		if element[""] != nil {
+			values = append(values, element[""])
+		}
+	}
+	return values, nil
+}
+
+// write replaces the stored values of the property with the values, an empty slice removes the property
+func (v multiValue) write(query *gremlingo.GraphTraversal) *gremlingo.GraphTraversal {
+	query = query.SideEffect(anonymousTraversal.Properties(v.key).Drop())
+	for _, value := range v.values {
+		query = query.Property(v.cardinality, v.key, value)
+	}
+	return query
+}
+
+// dedupeSlice removes repeated elements of a slice field tagged set, e.g. ones stored before the field was tagged
+func dedupeSlice(field reflect.Value) {
+	if field.Kind() != reflect.Slice {
+		return
+	}
+	seen := make(map[any]bool, field.Len())
+	n := 0
+	for i := range field.Len() {
+		element := field.Index(i)
+		if element.Comparable() {
+			if seen[element.Interface()] {
+				continue
+			}
+			seen[element.Interface()] = true
+		}
+		field.Index(n).Set(element)
+		n++
+	}
+	field.SetLen(n)
+}
@@ -0,0 +1,192 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testTagged struct {
+	gsmtypes.Vertex
+	Name    string   `json:"name"    gremlin:"name"`
+	Tags    []string `json:"tags"    gremlin:"tags,set"`
+	History []int    `json:"history" gremlin:"history,list"`
+	Aliases []string `json:"aliases" gremlin:"aliases,single"`
+	Notes   []string `json:"notes"   gremlin:"notes"`
+	Raw     []byte   `json:"raw"     gremlin:"raw"`
+}
+
+type testInvalidCardinality struct {
+	gsmtypes.Vertex
+	Name string `json:"name" gremlin:"name,set"`
+}
+
+func TestCardinalityBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestMultiValuedProperties", func(t *testing.T) {
+			t.Parallel()
+			properties, err := multiValuedProperties(Gremlin, reflect.TypeFor[testTagged]())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := map[string]any{"tags": cardinality.Set, "history": cardinality.List, "notes": cardinality.List}
+			if !reflect.DeepEqual(properties, expected) {
+				t.Errorf("Expected %v, got %v", expected, properties)
+			}
+			if _, err = multiValuedProperties(Neptune, reflect.TypeFor[testTagged]()); err == nil {
+				t.Error("Expected error for list cardinality on Neptune")
+			}
+			if _, err = multiValuedProperties(Gremlin, reflect.TypeFor[testInvalidCardinality]()); err == nil {
+				t.Error("Expected error for set cardinality on a string field")
+			}
+		},
+	)
+	t.Run(
+		"TestNeptuneDefault", func(t *testing.T) {
+			t.Parallel()
+			got, multi, err := fieldCardinality(Neptune, reflect.TypeFor[[]string](), gremlinTag{name: "notes"})
+			if err != nil || !multi || got != cardinality.Set {
+				t.Errorf("Expected set cardinality on Neptune, got %v %v %v", got, multi, err)
+			}
+		},
+	)
+	t.Run(
+		"TestMultiValueWrite", func(t *testing.T) {
+			t.Parallel()
+			value := multiValue{key: "tags", cardinality: cardinality.Set, values: []any{"a", "b"}}
+			expected := "g.V(1).sideEffect(properties('tags').drop()).property(set,'tags','a').property(set,'tags','b')"
+			if got := translate(t, value.write(db.g.V(1))); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		},
+	)
+	t.Run(
+		"TestMergeMultiValues", func(t *testing.T) {
+			t.Parallel()
+			tagged := testTagged{Name: "a", Tags: []string{"x"}, Aliases: []string{"b"}}
+			label, properties, err := structToMap(&tagged)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(properties, "id")
+			cardinalities, err := multiValuedProperties(Gremlin, reflect.TypeFor[testTagged]())
+			if err != nil {
+				t.Fatal(err)
+			}
+			merge, err := newVertexMerge(nil, label, properties, nil, cardinalities, time.Now().UTC())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := merge.onMatch["tags"]; ok || !reflect.DeepEqual(merge.onMatch["aliases"], []string{"b"}) {
+				t.Errorf("Expected only single valued properties in the merge maps, got %v", merge.onMatch)
+			}
+			got := translate(t, merge.traversal(db, nil))
+			for _, step := range []string{
+				"sideEffect(properties('history').drop())",
+				"sideEffect(properties('tags').drop()).property(set,'tags','x')",
+			} {
+				if !strings.Contains(got, step) {
+					t.Errorf("Expected %s in %s", step, got)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestUnloadSet", func(t *testing.T) {
+			t.Parallel()
+			var tagged testTagged
+			err := recursivelyUnloadIntoStruct(&tagged, map[string]any{
+				"tags":    []any{"a", "b", "a"},
+				"history": int64(3),
+				"aliases": []any{"b", "b"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(tagged.Tags, []string{"a", "b"}) || !slices.Equal(tagged.History, []int{3}) ||
+				!slices.Equal(tagged.Aliases, []string{"b", "b"}) {
+				t.Errorf("Expected deduplicated tags only, got %+v", tagged)
+			}
+		},
+	)
+	t.Run(
+		"TestUpdateInvalidCardinality", func(t *testing.T) {
+			t.Parallel()
+			var validationErr *ValidationError
+			err := Model[testInvalidCardinality](db).Update("name", "a")
+			if !errors.As(err, &validationErr) {
+				t.Errorf("Expected ValidationError, got %v", err)
+			}
+			if err = Create(db, &testInvalidCardinality{Name: "a"}); err == nil {
+				t.Error("Expected error creating a value with an invalid cardinality")
+			}
+		},
+	)
+}
+
+func TestCardinality(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+
+	tagged := testTagged{
+		Name:    "a",
+		Tags:    []string{"x", "y", "x"},
+		History: []int{1, 1, 2},
+		Aliases: []string{"b", "c"},
+		Notes:   []string{"n"},
+	}
+	if err = Create(db, &tagged); err != nil {
+		t.Fatal(err)
+	}
+	load := func() testTagged {
+		t.Helper()
+		found, findErr := Model[testTagged](db).ID(tagged.ID)
+		if findErr != nil {
+			t.Fatal(findErr)
+		}
+		return found
+	}
+	found := load()
+	slices.Sort(found.Tags)
+	if !slices.Equal(found.Tags, []string{"x", "y"}) || !slices.Equal(found.History, []int{1, 1, 2}) ||
+		!slices.Equal(found.Aliases, []string{"b", "c"}) || !slices.Equal(found.Notes, []string{"n"}) {
+		t.Errorf("Expected multi-valued properties to be stored per element, got %+v", found)
+	}
+	count, err := db.g.V(tagged.ID).Properties("history").Count().Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := count.GetInt(); n != 3 {
+		t.Errorf("Expected 3 history properties, got %d", n)
+	}
+
+	found.Tags = []string{"z"}
+	found.History = []int{4}
+	if err = Update(db, &found); err != nil {
+		t.Fatal(err)
+	}
+	if updated := load(); !slices.Equal(updated.Tags, []string{"z"}) || !slices.Equal(updated.History, []int{4}) {
+		t.Errorf("Expected the stored values to be replaced, got %+v", updated)
+	}
+
+	if err = Model[testTagged](db).Where("name", comparator.EQ, "a").Update("tags", []string{"p", "q"}); err != nil {
+		t.Fatal(err)
+	}
+	updated := load()
+	slices.Sort(updated.Tags)
+	if !slices.Equal(updated.Tags, []string{"p", "q"}) {
+		t.Errorf("Expected the stored tags to be replaced, got %v", updated.Tags)
+	}
+}
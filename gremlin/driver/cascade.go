@@ -0,0 +1,139 @@
+package driver
+
+import (
+	"fmt"
+	"reflect"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// cascadeRule is a single owned relationship, a vertex labeled parent owns the child vertices at the end of its out
+// edges labeled edge
+type cascadeRule struct {
+	parent string
+	edge   string
+	child  string
+	// softDelete is set when the child type embeds SoftDelete, its soft deleted vertices are only owned unscoped
+	softDelete bool
+}
+
+// DeleteCount is the number of vertices and edges a delete removes, see Query.DryRunDelete
+type DeleteCount struct {
+	Vertices int
+	Edges    int
+}
+
+// cascadeRules returns the owned relationships reachable from the vertex type rt
+// following the gremlinEdge fields tagged cascade of rt and of every owned type
+func cascadeRules(rt reflect.Type) ([]cascadeRule, error) {
+	rules := make([]cascadeRule, 0)
+	visited := map[reflect.Type]bool{}
+	pending := []reflect.Type{rt}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		parent, ok := reflect.New(current).Interface().(gsmtypes.VertexType)
+		if !ok {
+			return nil, fmt.Errorf("%s must be a vertex type to cascade deletes", current.Name())
+		}
+		for i := range current.NumField() {
+			field := current.Field(i)
+			tag := field.Tag.Get(gsmtypes.GremlinEdgeTag)
+			if tag == "" {
+				continue
+			}
+			edge, err := parseEdgeTag(tag)
+			if err != nil {
+				return nil, err
+			}
+			if !edge.cascade {
+				continue
+			}
+			childType, _, err := preloadFieldType(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("cascade field %s: %w", field.Name, err)
+			}
+			child, ok := reflect.New(childType).Interface().(gsmtypes.VertexType)
+			if !ok {
+				return nil, fmt.Errorf("cascade field %s must hold a vertex type", field.Name)
+			}
+			rules = append(rules, cascadeRule{
+				parent:     getLabelFromVertex(parent),
+				edge:       edge.label,
+				child:      getLabelFromVertex(child),
+				softDelete: embedsSoftDelete(childType),
+			})
+			pending = append(pending, childType)
+		}
+	}
+	return rules, nil
+}
+
+// withOwned continues query with the matched vertices followed by every vertex they own directly or transitively
+// the vertices are collected before they are returned so dropping them does not cut the traversal short
+// soft deleted owned vertices are skipped unless unscoped is set
+func withOwned(query *gremlingo.GraphTraversal, rules []cascadeRule, unscoped bool) *gremlingo.GraphTraversal {
+	if len(rules) == 0 {
+		return query
+	}
+	steps := make([]any, 0, len(rules))
+	for _, rule := range rules {
+		step := newAnonymousTraversal().HasLabel(rule.parent).Out(rule.edge).HasLabel(rule.child)
+		if rule.softDelete && !unscoped {
+			step = step.HasNot(gsmtypes.DeletedAt)
+		}
+		steps = append(steps, step)
+	}
+	owned := newAnonymousTraversal().
+		Repeat(newAnonymousTraversal().Union(steps...).SimplePath()).
+		Emit()
+	return query.Union(newAnonymousTraversal().Identity(), owned).Dedup().Fold().Unfold()
+}
+
+// dropVertices drops the vertices together with every vertex they own, see withOwned
+func dropVertices(rules []cascadeRule, unscoped bool) func(*gremlingo.GraphTraversal) *gremlingo.GraphTraversal {
+	return func(query *gremlingo.GraphTraversal) *gremlingo.GraphTraversal {
+		return withOwned(query, rules, unscoped).Drop()
+	}
+}
+
+// DryRunDelete returns the number of vertices and edges Delete would remove without changing the graph
+// for a soft delete the number of vertices which would be marked deleted is returned
+func (q *Query[T]) DryRunDelete() (DeleteCount, error) {
+	query, err := q.build()
+	if err != nil {
+		return DeleteCount{}, err
+	}
+	if q.softDelete && !q.unscoped {
+		result, countErr := await(q.db, query.Count().Next)
+		if countErr != nil {
+			return DeleteCount{}, countErr
+		}
+		vertices, countErr := result.GetInt()
+		return DeleteCount{Vertices: vertices}, countErr
+	}
+	rules, err := cascadeRules(reflect.TypeFor[T]())
+	if err != nil {
+		return DeleteCount{}, err
+	}
+	query = withOwned(query, rules, q.unscoped).Fold().
+		Project("vertices", "edges").
+		By(newAnonymousTraversal().Count(Scope.Local)).
+		By(newAnonymousTraversal().Unfold().BothE().Dedup().Count())
+	result, err := await(q.db, query.Next)
+	if err != nil {
+		return DeleteCount{}, err
+	}
+	counts, ok := result.GetInterface().(map[any]any)
+	if !ok {
+		return DeleteCount{}, fmt.Errorf("unexpected dry run result %T", result.GetInterface())
+	}
+	vertices, _ := counts["vertices"].(int64)
+	edges, _ := counts["edges"].(int64)
+	return DeleteCount{Vertices: int(vertices), Edges: int(edges)}, nil
+}
@@ -0,0 +1,115 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testFolder struct {
+	gsmtypes.Vertex
+	Name    string        `json:"name"    gremlin:"name"`
+	Folders []*testFolder `json:"folders" gremlinEdge:"test_edge,cascade"`
+	Files   []testFile    `json:"files"   gremlinEdge:"test_edge,out,cascade"`
+	Shared  []testFile    `json:"shared"  gremlinEdge:"test_shared"`
+}
+
+type testShared struct {
+	gsmtypes.Edge
+}
+
+type testFile struct {
+	gsmtypes.Vertex
+	Name string `json:"name" gremlin:"name"`
+}
+
+func TestCascadeBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestCascadeRules", func(t *testing.T) {
+			t.Parallel()
+			rules, err := cascadeRules(reflect.TypeFor[testFolder]())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []cascadeRule{
+				{parent: "test_folder", edge: "test_edge", child: "test_folder"},
+				{parent: "test_folder", edge: "test_edge", child: "test_file"},
+			}
+			if !reflect.DeepEqual(rules, expected) {
+				t.Errorf("Expected %v, got %v", expected, rules)
+			}
+			if rules, _ = cascadeRules(reflect.TypeFor[testVertex]()); len(rules) != 0 {
+				t.Errorf("Expected no rules, got %v", rules)
+			}
+		},
+	)
+	t.Run(
+		"TestCascadeDrop", func(t *testing.T) {
+			t.Parallel()
+			rules := []cascadeRule{{parent: "test_folder", edge: "test_edge", child: "test_file"}}
+			expected := "g.V(1).union(identity(),repeat(union(hasLabel('test_folder').out('test_edge')." +
+				"hasLabel('test_file')).simplePath()).emit()).dedup().fold().unfold().drop()"
+			if got := translate(t, dropVertices(rules, false)(db.g.V(1))); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+			if got := translate(t, dropVertices(nil, false)(db.g.V(1))); got != "g.V(1).drop()" {
+				t.Errorf("Expected a plain drop without rules, got %s", got)
+			}
+		},
+	)
+}
+
+func TestCascade(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+
+	root := testFolder{Name: "root"}
+	child := testFolder{Name: "child"}
+	file := testFile{Name: "file"}
+	shared := testFile{Name: "shared"}
+	for _, err = range []error{Create(db, &root), Create(db, &child), Create(db, &file), Create(db, &shared)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, link := range []struct {
+		from gsmtypes.VertexType
+		to   gsmtypes.VertexType
+	}{{root, child}, {child, file}} {
+		if err = CreateEdge(db, &testEdge{}, link.from, link.to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = CreateEdge(db, &testShared{}, root, shared); err != nil {
+		t.Fatal(err)
+	}
+
+	query := func() *Query[testFolder] {
+		return Model[testFolder](db).Where("name", comparator.EQ, "root")
+	}
+	count, err := query().DryRunDelete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// root with the owned child folder and file, the shared file is not owned but its edge is removed
+	if count.Vertices != 3 || count.Edges != 3 {
+		t.Errorf("Expected 3 vertices and 3 edges, got %+v", count)
+	}
+	if err = query().Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Model[testFile](db).ID(file.ID); err == nil {
+		t.Error("Expected owned file to be dropped")
+	}
+	if _, err = Model[testFile](db).ID(shared.ID); err != nil {
+		t.Errorf("Expected shared file to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+)
+
+// conditionGroup is the boolean step a group of conditions is compiled into
+type conditionGroup string
+
+const (
+	groupOr  conditionGroup = "Or"
+	groupAnd conditionGroup = "And"
+	groupNot conditionGroup = "Not"
+)
+
+type QueryCondition struct {
+	field     string
+	operator  comparator.Comparator
+	value     any
+	traversal *gremlingo.GraphTraversal
+	group     conditionGroup
+	children  []*QueryCondition
+}
+
+// Cond creates a condition which can be combined into groups with Or, And and Not
+func Cond(field string, operator comparator.Comparator, value any) *QueryCondition {
+	return &QueryCondition{
+		field:    field,
+		operator: operator,
+		value:    value,
+	}
+}
+
+// Or creates a condition group which matches when any of the conditions match
+func Or(conditions ...*QueryCondition) *QueryCondition {
+	return &QueryCondition{group: groupOr, children: conditions}
+}
+
+// And creates a condition group which matches when all of the conditions match
+func And(conditions ...*QueryCondition) *QueryCondition {
+	return &QueryCondition{group: groupAnd, children: conditions}
+}
+
+// Not creates a condition which matches when the given condition does not match
+func Not(condition *QueryCondition) *QueryCondition {
+	return &QueryCondition{group: groupNot, children: []*QueryCondition{condition}}
+}
+
+// predicateNames holds the name used in the debug string for each comparator compiled into a predicate
+var predicateNames = map[comparator.Comparator]string{
+	comparator.EQ:              "P.Eq",
+	"eq":                       "P.Eq",
+	comparator.NEQ:             "P.Neq",
+	"neq":                      "P.Neq",
+	comparator.GT:              "P.Gt",
+	"gt":                       "P.Gt",
+	comparator.GTE:             "P.Gte",
+	"gte":                      "P.Gte",
+	comparator.LT:              "P.Lt",
+	"lt":                       "P.Lt",
+	comparator.LTE:             "P.Lte",
+	"lte":                      "P.Lte",
+	comparator.IN:              "P.Within",
+	comparator.WITHOUT:         "P.Without",
+	comparator.BETWEEN:         "P.Between",
+	comparator.INSIDE:          "P.Inside",
+	comparator.OUTSIDE:         "P.Outside",
+	comparator.CONTAINS:        "TextP.Containing",
+	comparator.NOT_CONTAINS:    "TextP.NotContaining",
+	comparator.STARTS_WITH:     "TextP.StartingWith",
+	comparator.NOT_STARTS_WITH: "TextP.NotStartingWith",
+	comparator.ENDS_WITH:       "TextP.EndingWith",
+	comparator.REGEX:           "TextP.Regex",
+}
+
+func (qc *QueryCondition) String() string {
+	if qc == nil {
+		return "<nil>"
+	}
+	if qc.traversal != nil {
+		return ""
+	}
+
+	if qc.group != "" {
+		children := make([]string, 0, len(qc.children))
+		for _, child := range qc.children {
+			children = append(children, strings.TrimPrefix(child.String(), "."))
+		}
+		return fmt.Sprintf(".%s(%s)", qc.group, strings.Join(children, ", "))
+	}
+
+	switch qc.operator { //nolint: exhaustive // every other comparator is compiled into a predicate
+	case comparator.EXISTS:
+		return fmt.Sprintf(".Has(%s)", qc.field)
+	case comparator.NOT_EXISTS:
+		return fmt.Sprintf(".HasNot(%s)", qc.field)
+	}
+
+	if qc.field == "id" {
+		if qc.operator == comparator.EQ || qc.operator == "eq" {
+			return fmt.Sprintf(".HasId(%v)", qc.value)
+		}
+		return fmt.Sprintf(".HasId(%s(%v))", predicateNames[qc.operator], qc.value)
+	}
+	return fmt.Sprintf(".Has(%s, %s(%v))", qc.field, predicateNames[qc.operator], qc.value)
+}
+
+// validateCondition reports every problem of the condition and its children without compiling it
+// fields holds the gremlin tag names of the model being queried, the id field is always accepted
+func validateCondition(condition *QueryCondition, fields map[string]struct{}) error {
+	if condition == nil {
+		return errors.New("condition is nil")
+	}
+	if condition.traversal != nil {
+		return nil
+	}
+	errs := make([]error, 0)
+	if condition.group != "" {
+		if len(condition.children) == 0 {
+			return fmt.Errorf("%s condition group has no conditions", condition.group)
+		}
+		for _, child := range condition.children {
+			if err := validateCondition(child, fields); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	if !hasGremlinField(fields, condition.field) && condition.field != "id" {
+		errs = append(errs, fmt.Errorf("field %s is not a gremlin tag of the model", condition.field))
+	}
+	if condition.operator != comparator.EXISTS && condition.operator != comparator.NOT_EXISTS {
+		if _, err := conditionPredicate(condition); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addQueryConditions appends the steps for each condition to the query
+// conditions which cannot be compiled are left out and reported in the returned error
+func addQueryConditions(query *gremlingo.GraphTraversal, conditions []*QueryCondition) error {
+	errs := make([]error, 0)
+	for _, condition := range conditions {
+		if err := applyCondition(query, condition); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// conditionTraversals compiles every condition into its own anonymous traversal
+// so they can be passed to the or, and and not steps
+func conditionTraversals(conditions []*QueryCondition) ([]any, error) {
+	traversals := make([]any, 0, len(conditions))
+	for _, condition := range conditions {
+		traversal := newAnonymousTraversal()
+		if err := applyCondition(traversal, condition); err != nil {
+			return nil, err
+		}
+		traversals = append(traversals, traversal)
+	}
+	return traversals, nil
+}
+
+// applyCondition appends the step for a single condition to the query
+// nothing is appended when the condition cannot be compiled
+func applyCondition(query *gremlingo.GraphTraversal, condition *QueryCondition) error {
+	if condition == nil {
+		return errors.New("condition is nil")
+	}
+	if condition.traversal != nil {
+		query.Where(condition.traversal)
+		return nil
+	}
+	if condition.group != "" {
+		return applyConditionGroup(query, condition)
+	}
+	switch condition.operator { //nolint: exhaustive // every other comparator is compiled into a predicate
+	case comparator.EXISTS:
+		query.Has(condition.field)
+		return nil
+	case comparator.NOT_EXISTS:
+		query.HasNot(condition.field)
+		return nil
+	}
+	predicate, err := conditionPredicate(condition)
+	if err != nil {
+		return err
+	}
+	if condition.field == "id" {
+		query.HasId(predicate)
+	} else {
+		query.Has(condition.field, predicate)
+	}
+	return nil
+}
+
+func applyConditionGroup(query *gremlingo.GraphTraversal, condition *QueryCondition) error {
+	children, err := conditionTraversals(condition.children)
+	if err != nil {
+		return err
+	}
+	switch condition.group {
+	case groupOr:
+		query.Or(children...)
+	case groupAnd:
+		query.And(children...)
+	case groupNot:
+		query.Not(children...)
+	}
+	return nil
+}
+
+// conditionPredicate compiles the operator and value of a condition into the value passed to the has step
+func conditionPredicate(condition *QueryCondition) (any, error) {
+	switch condition.operator { //nolint: exhaustive // exists and not exists are not predicates
+	case comparator.EQ, "eq":
+		return condition.value, nil
+	case comparator.NEQ, "neq":
+		return gremlingo.P.Neq(condition.value), nil
+	case comparator.GT, "gt":
+		return gremlingo.P.Gt(condition.value), nil
+	case comparator.GTE, "gte":
+		return gremlingo.P.Gte(condition.value), nil
+	case comparator.LT, "lt":
+		return gremlingo.P.Lt(condition.value), nil
+	case comparator.LTE, "lte":
+		return gremlingo.P.Lte(condition.value), nil
+	case comparator.IN, comparator.WITHOUT:
+		return listPredicate(condition)
+	case comparator.BETWEEN, comparator.INSIDE, comparator.OUTSIDE:
+		return rangePredicate(condition)
+	case comparator.CONTAINS, comparator.NOT_CONTAINS, comparator.STARTS_WITH,
+		comparator.NOT_STARTS_WITH, comparator.ENDS_WITH, comparator.REGEX:
+		return textPredicate(condition)
+	}
+	return nil, fmt.Errorf("unknown comparator %q on field %s", condition.operator, condition.field)
+}
+
+func listPredicate(condition *QueryCondition) (any, error) {
+	values, ok := toAnySlice(condition.value)
+	if !ok {
+		return nil, fmt.Errorf(
+			"comparator %q on field %s expects a slice value, got %T",
+			condition.operator, condition.field, condition.value,
+		)
+	}
+	if condition.operator == comparator.WITHOUT {
+		return gremlingo.P.Without(values...), nil
+	}
+	return gremlingo.P.Within(values...), nil
+}
+
+func rangePredicate(condition *QueryCondition) (any, error) {
+	bounds, ok := toAnySlice(condition.value)
+	if !ok || len(bounds) != 2 { //nolint: mnd // a range is a lower and an upper bound
+		return nil, fmt.Errorf(
+			"comparator %q on field %s expects a slice value holding a lower and upper bound, got %v",
+			condition.operator, condition.field, condition.value,
+		)
+	}
+	switch condition.operator { //nolint: exhaustive // only range comparators reach this point
+	case comparator.INSIDE:
+		return gremlingo.P.Inside(bounds[0], bounds[1]), nil
+	case comparator.OUTSIDE:
+		return gremlingo.P.Outside(bounds[0], bounds[1]), nil
+	}
+	return gremlingo.P.Between(bounds[0], bounds[1]), nil
+}
+
+func textPredicate(condition *QueryCondition) (any, error) {
+	text, ok := condition.value.(string)
+	if !ok {
+		return nil, fmt.Errorf(
+			"comparator %q on field %s expects a string value, got %T",
+			condition.operator, condition.field, condition.value,
+		)
+	}
+	switch condition.operator { //nolint: exhaustive // only text comparators reach this point
+	case comparator.NOT_CONTAINS:
+		return gremlingo.TextP.NotContaining(text), nil
+	case comparator.STARTS_WITH:
+		return gremlingo.TextP.StartingWith(text), nil
+	case comparator.NOT_STARTS_WITH:
+		return gremlingo.TextP.NotStartingWith(text), nil
+	case comparator.ENDS_WITH:
+		return gremlingo.TextP.EndingWith(text), nil
+	case comparator.REGEX:
+		return gremlingo.TextP.Regex(text), nil
+	}
+	return gremlingo.TextP.Containing(text), nil
+}
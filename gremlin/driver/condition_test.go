@@ -0,0 +1,248 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+)
+
+func TestConditionBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	tests := []struct {
+		testName string
+		query    *Query[testVertexForUtils]
+		expected string
+	}{
+		{
+			testName: "TestQueryOr",
+			query: Model[testVertexForUtils](db).Or(
+				Cond("name", comparator.EQ, "first"),
+				Cond("sort", comparator.GT, 2),
+			),
+			expected: "g.V().hasLabel('test_vertex_for_utils').or(has('name','first'),has('sort',gt(2)))",
+		},
+		{
+			testName: "TestQueryNestedGroups",
+			query: Model[testVertexForUtils](db).
+				Where("sort", comparator.LT, 10).
+				Or(
+					And(Cond("name", comparator.EQ, "first"), Cond("sort", comparator.EQ, 1)),
+					Not(Cond("name", comparator.IN, []any{"second", "third"})),
+				),
+			expected: "g.V().hasLabel('test_vertex_for_utils').has('sort',lt(10))" +
+				".or(and(has('name','first'),has('sort',1)),not(has('name',within(['second','third']))))",
+		},
+		{
+			testName: "TestQueryNot",
+			query:    Model[testVertexForUtils](db).Not(Cond("id", comparator.EQ, 1)),
+			expected: "g.V().hasLabel('test_vertex_for_utils').not(hasId(1))",
+		},
+		{
+			testName: "TestQueryInTypedSlice",
+			query:    Model[testVertexForUtils](db).Where("name", comparator.IN, []string{"first", "second"}),
+			expected: "g.V().hasLabel('test_vertex_for_utils').has('name',within(['first','second']))",
+		},
+		{
+			testName: "TestQueryWithoutTypedSlice",
+			query:    Model[testVertexForUtils](db).Where("id", comparator.WITHOUT, []int{1, 2}),
+			expected: "g.V().hasLabel('test_vertex_for_utils').hasId(without([1,2]))",
+		},
+		{
+			testName: "TestQueryEmbeddedVertexField",
+			query:    Model[testVertexForUtils](db).Where("created_at", comparator.EXISTS, nil),
+			expected: "g.V().hasLabel('test_vertex_for_utils').has('created_at')",
+		},
+		{
+			testName: "TestQueryAnd",
+			query: Model[testVertexForUtils](db).And(
+				Cond("name", comparator.NEQ, "first"),
+				Cond("name", comparator.CONTAINS, "ir"),
+			),
+			expected: "g.V().hasLabel('test_vertex_for_utils')" +
+				".and(has('name',neq('first')),has('name',containing('ir')))",
+		},
+	}
+	comparatorTests := []struct {
+		operator comparator.Comparator
+		value    any
+		expected string
+	}{
+		{operator: comparator.BETWEEN, value: []any{1, 5}, expected: "has('sort',between(1,5))"},
+		{operator: comparator.BETWEEN, value: [2]int{1, 5}, expected: "has('sort',between(1,5))"},
+		{operator: comparator.INSIDE, value: []any{1, 5}, expected: "has('sort',inside(1,5))"},
+		{operator: comparator.OUTSIDE, value: []any{1, 5}, expected: "has('sort',outside([1,5]))"},
+		{operator: comparator.STARTS_WITH, value: "fi", expected: "has('sort',startingWith('fi'))"},
+		{operator: comparator.ENDS_WITH, value: "st", expected: "has('sort',endingWith('st'))"},
+		{operator: comparator.NOT_CONTAINS, value: "ir", expected: "has('sort',notContaining('ir'))"},
+		{
+			operator: comparator.NOT_STARTS_WITH,
+			value:    "fi",
+			expected: "has('sort',notStartingWith('fi'))",
+		},
+		{operator: comparator.REGEX, value: "^f.*t$", expected: "has('sort',regex('^f.*t$'))"},
+		{operator: comparator.EXISTS, expected: "has('sort')"},
+		{operator: comparator.NOT_EXISTS, expected: "hasNot('sort')"},
+	}
+	for _, tt := range comparatorTests {
+		tests = append(tests, struct {
+			testName string
+			query    *Query[testVertexForUtils]
+			expected string
+		}{
+			testName: fmt.Sprintf("TestComparator_%s_%T", tt.operator, tt.value),
+			query:    Model[testVertexForUtils](db).Where("sort", tt.operator, tt.value),
+			expected: "g.V().hasLabel('test_vertex_for_utils')." + tt.expected,
+		})
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.testName, func(t *testing.T) {
+				t.Parallel()
+				if got := translate(t, tt.query.BuildQuery()); got != tt.expected {
+					t.Errorf("Expected %s, got %s", tt.expected, got)
+				}
+			},
+		)
+	}
+
+	invalidConditionTests := []struct {
+		testName string
+		query    *Query[testVertexForUtils]
+	}{
+		{
+			testName: "TestContainsNonString",
+			query:    Model[testVertexForUtils](db).Where("sort", comparator.CONTAINS, 1),
+		},
+		{
+			testName: "TestBetweenSingleValue",
+			query:    Model[testVertexForUtils](db).Where("sort", comparator.BETWEEN, []any{1}),
+		},
+		{
+			testName: "TestInNonSlice",
+			query:    Model[testVertexForUtils](db).Where("name", comparator.IN, "first"),
+		},
+		{
+			testName: "TestUnknownComparator",
+			query:    Model[testVertexForUtils](db).Where("name", "like", "first"),
+		},
+		{
+			testName: "TestInvalidNestedCondition",
+			query: Model[testVertexForUtils](db).Or(
+				Cond("name", comparator.EQ, "first"),
+				Not(Cond("name", comparator.REGEX, 1)),
+			),
+		},
+		{
+			testName: "TestUnknownField",
+			query:    Model[testVertexForUtils](db).Where("nmae", comparator.EQ, "first"),
+		},
+		{
+			testName: "TestUnknownNestedField",
+			query:    Model[testVertexForUtils](db).Not(Cond("subTraversalTest", comparator.EXISTS, nil)),
+		},
+		{
+			testName: "TestEmptyGroup",
+			query:    Model[testVertexForUtils](db).Or(),
+		},
+		{
+			testName: "TestNilCondition",
+			query:    Model[testVertexForUtils](db).Not(nil),
+		},
+	}
+	for _, tt := range invalidConditionTests {
+		t.Run(
+			tt.testName, func(t *testing.T) {
+				t.Parallel()
+				// the offline driver has no connection so these only pass when the query is never sent
+				var validationErr *ValidationError
+				if _, err := tt.query.Count(); !errors.As(err, &validationErr) {
+					t.Errorf("Expected ValidationError from Count, got %v", err)
+				}
+				if _, err := tt.query.Find(); !errors.As(err, &validationErr) {
+					t.Errorf("Expected ValidationError from Find, got %v", err)
+				}
+				if err := tt.query.Delete(); !errors.As(err, &validationErr) {
+					t.Errorf("Expected ValidationError from Delete, got %v", err)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"TestValidationErrorCollectsAll", func(t *testing.T) {
+			t.Parallel()
+			_, err := Model[testVertexForUtils](db).
+				Where("nmae", comparator.EQ, "first").
+				Where("sort", "like", 1).
+				Count()
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected ValidationError, got %v", err)
+			}
+			if len(validationErr.Errs) != 2 {
+				t.Errorf("Expected 2 errors, got %d: %v", len(validationErr.Errs), validationErr.Errs)
+			}
+		},
+	)
+
+	t.Run(
+		"TestUpdateInvalidValue", func(t *testing.T) {
+			t.Parallel()
+			var validationErr *ValidationError
+			err := Model[testVertexForUtils](db).Where("name", comparator.EQ, "first").Update("listTest", "a")
+			if !errors.As(err, &validationErr) {
+				t.Errorf("Expected ValidationError for non slice value, got %v", err)
+			}
+			err = Model[testVertexForUtils](db).Where("name", comparator.IN, "first").Update("name", "second")
+			if !errors.As(err, &validationErr) {
+				t.Errorf("Expected ValidationError for invalid condition, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"TestEdgeQueryUnknownField", func(t *testing.T) {
+			t.Parallel()
+			var validationErr *ValidationError
+			if _, err := EdgeModel[testEdge](db).Where("rol", comparator.EQ, "owner").Count(); !errors.As(
+				err,
+				&validationErr,
+			) {
+				t.Errorf("Expected ValidationError, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"TestBuildQueryMatchesNothingWhenInvalid", func(t *testing.T) {
+			t.Parallel()
+			query := Model[testVertexForUtils](db).Where("nmae", comparator.EQ, "first").BuildQuery().Drop()
+			expected := "g.V().hasLabel('test_vertex_for_utils').has('nmae','first').limit(0).drop()"
+			if got := translate(t, query); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+			edges := EdgeModel[testEdge](db).Where("rol", comparator.EQ, "owner").BuildQuery()
+			if got := translate(t, edges); got != "g.E().hasLabel('test_edge').has('rol','owner').limit(0)" {
+				t.Errorf("Expected the edge traversal to match nothing, got %s", got)
+			}
+		},
+	)
+
+	t.Run(
+		"TestConditionGroupString", func(t *testing.T) {
+			t.Parallel()
+			condition := Or(
+				Cond("name", comparator.EQ, "first"),
+				Not(Cond("sort", comparator.GTE, 2)),
+				Cond("deleted", comparator.NOT_EXISTS, nil),
+			)
+			expected := ".Or(Has(name, P.Eq(first)), Not(Has(sort, P.Gte(2))), HasNot(deleted))"
+			if got := condition.String(); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		},
+	)
+}
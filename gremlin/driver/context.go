@@ -0,0 +1,85 @@
+package driver
+
+import (
+	"context"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// evaluationTimeoutKey is the request option the gremlin server reads the per request timeout in milliseconds from
+const evaluationTimeoutKey = "evaluationTimeout"
+
+// WithContext returns a copy of the driver bound to ctx, the copy shares the connection of the driver
+// every operation run through the copy stops waiting for the server once ctx is done and returns ErrQueryAborted
+// when ctx has a deadline the remaining time is sent to the server as the evaluationTimeout request option
+// the option replaces the one of a driver which is already bound, the driver itself is left unchanged
+func (driver *GremlinDriver) WithContext(ctx context.Context) *GremlinDriver {
+	bound := *driver
+	bound.ctx = ctx
+	bound.bindSource(driver.unboundSource())
+	return &bound
+}
+
+// unboundSource returns the traversal source of the driver without the evaluationTimeout option
+func (driver *GremlinDriver) unboundSource() *gremlingo.GraphTraversalSource {
+	if driver.source == nil {
+		return driver.g
+	}
+	return driver.source
+}
+
+// bindSource sets g to source with the evaluationTimeout option of the deadline of the context of the driver
+// With adds a new OptionsStrategy to a source without one, so the options are never shared with another driver
+func (driver *GremlinDriver) bindSource(source *gremlingo.GraphTraversalSource) {
+	driver.source = source
+	driver.g = source
+	if deadline, ok := driver.context().Deadline(); ok {
+		driver.g = source.With(evaluationTimeoutKey, max(time.Until(deadline).Milliseconds(), 1))
+	}
+}
+
+// context returns the context the driver is bound to, context.Background when it is not bound
+func (driver *GremlinDriver) context() context.Context {
+	if driver.ctx == nil {
+		return context.Background()
+	}
+	return driver.ctx
+}
+
+// await runs call and waits for it to finish or for the context of the driver to be done
+// nothing is sent when the context is already done, a call which is still running when the context is done
+// keeps running in the background and its result is discarded
+func await[R any](db *GremlinDriver, call func() (R, error)) (R, error) {
+	ctx := db.context()
+	var zero R
+	if err := ctx.Err(); err != nil {
+		return zero, newAbortedError(err)
+	}
+	if ctx.Done() == nil {
+		return call()
+	}
+	type result struct {
+		value R
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := call()
+		done <- result{value: value, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, newAbortedError(ctx.Err())
+	}
+}
+
+// awaitIterate iterates the traversal and waits for it to finish or for the context of the driver to be done
+func awaitIterate(db *GremlinDriver, iterate func() <-chan error) error {
+	_, err := await(db, func() (struct{}, error) {
+		return struct{}{}, <-iterate()
+	})
+	return err
+}
@@ -0,0 +1,150 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+)
+
+func TestContextOffline(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		testName string
+		run      func() error
+	}{
+		{
+			testName: "TestQueryFind",
+			run: func() error {
+				_, err := Model[testVertex](db).WithContext(cancelled).Where("name", comparator.EQ, "first").Find()
+				return err
+			},
+		},
+		{
+			testName: "TestQueryCount",
+			run: func() error {
+				_, err := Model[testVertex](db).WithContext(cancelled).Count()
+				return err
+			},
+		},
+		{
+			testName: "TestQueryDelete",
+			run: func() error {
+				return Model[testVertex](db).WithContext(cancelled).Delete()
+			},
+		},
+		{
+			testName: "TestQueryUpdate",
+			run: func() error {
+				return Model[testVertex](db).WithContext(cancelled).Update("name", "second")
+			},
+		},
+		{
+			testName: "TestCreate",
+			run: func() error {
+				return Create(db.WithContext(cancelled), &testVertex{Name: "first"})
+			},
+		},
+		{
+			testName: "TestEdgeQueryFind",
+			run: func() error {
+				_, err := EdgeModel[testEdge](db).WithContext(cancelled).Find()
+				return err
+			},
+		},
+		{
+			testName: "TestRawQuery",
+			run: func() error {
+				_, err := db.Label("test_vertex").WithContext(cancelled).ToList()
+				return err
+			},
+		},
+		{
+			testName: "TestTraverse",
+			run: func() error {
+				_, err := Traverse[testVertex, testVertexForUtils](Model[testVertex](db), "owns", Out).
+					WithContext(cancelled).
+					Find()
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.testName, func(t *testing.T) {
+				t.Parallel()
+				// the offline driver has no connection so these only pass when nothing is sent
+				err := tt.run()
+				if !errors.Is(err, ErrQueryAborted) || !errors.Is(err, context.Canceled) {
+					t.Errorf("Expected ErrQueryAborted wrapping context.Canceled, got %v", err)
+				}
+			},
+		)
+	}
+
+	t.Run(
+		"TestEvaluationTimeout", func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			got := translate(t, Model[testVertex](db).WithContext(ctx).BuildQuery())
+			if !strings.Contains(got, "OptionsStrategy") || !strings.Contains(got, evaluationTimeoutKey) {
+				t.Errorf("Expected evaluationTimeout option, got %s", got)
+			}
+			traversed := Traverse[testVertex, testVertexForUtils](Model[testVertex](db), "owns", Out).WithContext(ctx)
+			if got = translate(t, traversed.BuildQuery()); !strings.Contains(got, evaluationTimeoutKey) {
+				t.Errorf("Expected evaluationTimeout option on traversed query, got %s", got)
+			}
+			if got = translate(t, Model[testVertex](db).BuildQuery()); strings.Contains(got, evaluationTimeoutKey) {
+				t.Errorf("Expected no evaluationTimeout option without a context, got %s", got)
+			}
+		},
+	)
+
+	t.Run(
+		"TestNestedContext", func(t *testing.T) {
+			t.Parallel()
+			outer, cancelOuter := context.WithTimeout(context.Background(), time.Hour)
+			defer cancelOuter()
+			base := db.WithContext(outer)
+			before := translate(t, base.g.V())
+			inner, cancelInner := context.WithTimeout(context.Background(), time.Second)
+			defer cancelInner()
+			nested := base.WithContext(inner)
+			if got := translate(t, base.g.V()); got != before {
+				t.Errorf("Expected the outer driver to keep %s, got %s", before, got)
+			}
+			got := translate(t, nested.g.V())
+			if strings.Count(got, evaluationTimeoutKey) != 1 || got == before {
+				t.Errorf("Expected a single evaluationTimeout option of the inner context, got %s", got)
+			}
+			unbound := base.WithContext(context.Background())
+			if got = translate(t, unbound.g.V()); strings.Contains(got, evaluationTimeoutKey) {
+				t.Errorf("Expected no evaluationTimeout option without a deadline, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestAwaitStopsWaiting", func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			block := make(chan struct{})
+			defer close(block)
+			_, err := await(db.WithContext(ctx), func() (int, error) {
+				<-block
+				return 1, nil
+			})
+			if !errors.Is(err, ErrQueryAborted) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected ErrQueryAborted wrapping context.DeadlineExceeded, got %v", err)
+			}
+		},
+	)
+}
@@ -0,0 +1,183 @@
+package driver
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// asInterface returns the value or, for methods with a pointer receiver, the address of the value as I
+func asInterface[I any](rv reflect.Value) (I, bool) {
+	if rv.CanInterface() {
+		if i, ok := rv.Interface().(I); ok {
+			return i, true
+		}
+	}
+	if rv.CanAddr() && rv.Addr().CanInterface() {
+		if i, ok := rv.Addr().Interface().(I); ok {
+			return i, true
+		}
+	}
+	var zero I
+	return zero, false
+}
+
+// convertsItself reports whether values of type rt, or pointers to them, convert themselves to and from properties
+func convertsItself(rt reflect.Type) bool {
+	for _, it := range []reflect.Type{
+		reflect.TypeFor[gsmtypes.GremlinMarshaler](),
+		reflect.TypeFor[gsmtypes.GremlinUnmarshaler](),
+		reflect.TypeFor[driver.Valuer](),
+		reflect.TypeFor[sql.Scanner](),
+		reflect.TypeFor[encoding.TextMarshaler](),
+	} {
+		if rt.Implements(it) || reflect.PointerTo(rt).Implements(it) {
+			return true
+		}
+	}
+	return false
+}
+
+// setPropertyValue stores the value gremlin-go serializes for a field under name
+// nil pointers and invalid sql null values are stored as nil so the property is left unset or removed
+// GremlinMarshaler, driver.Valuer and encoding.TextMarshaler values are encoded first
+func setPropertyValue(properties map[string]any, name string, rv reflect.Value) error {
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		properties[name] = nil
+		return nil
+	}
+	if marshaler, ok := asInterface[gsmtypes.GremlinMarshaler](rv); ok {
+		value, err := marshaler.MarshalGremlin()
+		properties[name] = value
+		return err
+	}
+	if valuer, ok := asInterface[driver.Valuer](rv); ok {
+		value, err := valuer.Value()
+		properties[name] = value
+		return err
+	}
+	if rv.Kind() == reflect.Pointer {
+		return setPropertyValue(properties, name, rv.Elem())
+	}
+	if marshaler, ok := asInterface[encoding.TextMarshaler](rv); ok && rv.Type() != reflect.TypeFor[time.Time]() {
+		text, err := marshaler.MarshalText()
+		properties[name] = string(text)
+		return err
+	}
+	properties[name] = rv.Interface()
+	return nil
+}
+
+// assignValue stores a gremlin value in field converting it to the type of the field
+// pointers are allocated, GremlinUnmarshaler, sql.Scanner and encoding.TextUnmarshaler fields load themselves
+// and time.Time fields also accept unix milliseconds and RFC 3339 strings
+func assignValue(field reflect.Value, value any) error {
+	if field.Kind() == reflect.Pointer {
+		if value == nil {
+			field.SetZero()
+			return nil
+		}
+		elem := reflect.New(field.Type().Elem())
+		if err := assignValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	if field.Kind() != reflect.Interface {
+		if unmarshaler, ok := asInterface[gsmtypes.GremlinUnmarshaler](field); ok {
+			return unmarshaler.UnmarshalGremlin(value)
+		}
+		if scanner, ok := asInterface[sql.Scanner](field); ok {
+			return scanner.Scan(value)
+		}
+	}
+	if value == nil {
+		field.SetZero()
+		return nil
+	}
+	if field.Type() == reflect.TypeFor[time.Time]() {
+		parsed, err := toTime(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(parsed))
+		return nil
+	}
+	if text, isString := value.(string); isString {
+		if unmarshaler, ok := asInterface[encoding.TextUnmarshaler](field); ok {
+			return unmarshaler.UnmarshalText([]byte(text))
+		}
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+	case isConvertible(rv.Type(), field.Type()):
+		field.Set(rv.Convert(field.Type()))
+	case rv.Kind() == reflect.Slice && field.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), rv.Len(), rv.Len())
+		for i := range rv.Len() {
+			if err := assignValue(slice.Index(i), rv.Index(i).Interface()); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		field.Set(slice)
+	case field.Kind() == reflect.Slice:
+		// a list property holding a single value is returned as the value itself
+		slice := reflect.MakeSlice(field.Type(), 1, 1)
+		if err := assignValue(slice.Index(0), value); err != nil {
+			return err
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("cannot store %T in %s", value, field.Type())
+	}
+	return nil
+}
+
+// isConvertible reports whether a value of type from can be converted to type to without changing its meaning
+// reflect allows converting integers to strings as runes which is never what a property holds
+func isConvertible(from reflect.Type, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	if to.Kind() == reflect.String {
+		return from.Kind() == reflect.String || from.Kind() == reflect.Slice
+	}
+	return true
+}
+
+// toTime converts a time.Time, unix milliseconds or a RFC 3339 string into a time
+func toTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case int64:
+		return time.UnixMilli(v).UTC(), nil
+	case int32:
+		return time.UnixMilli(int64(v)).UTC(), nil
+	case int:
+		return time.UnixMilli(int64(v)).UTC(), nil
+	case float64:
+		return time.UnixMilli(int64(v)).UTC(), nil
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return parsed, nil
+		}
+		millis, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse %q as a RFC 3339 time or unix milliseconds", v)
+		}
+		return time.UnixMilli(millis).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("cannot store %T in time.Time", value)
+	}
+}
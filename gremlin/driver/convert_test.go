@@ -0,0 +1,211 @@
+package driver
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// testCode is stored as text through encoding.TextMarshaler
+type testCode struct {
+	prefix string
+	number int
+}
+
+func (c testCode) MarshalText() ([]byte, error) {
+	return fmt.Appendf(nil, "%s-%d", c.prefix, c.number), nil
+}
+
+func (c *testCode) UnmarshalText(text []byte) error {
+	prefix, number, ok := strings.Cut(string(text), "-")
+	if !ok {
+		return fmt.Errorf("invalid code %q", text)
+	}
+	c.prefix = prefix
+	_, err := fmt.Sscan(number, &c.number)
+	return err
+}
+
+// testCelsius is stored as tenths of a degree through gsmtypes.GremlinMarshaler
+type testCelsius float64
+
+func (c testCelsius) MarshalGremlin() (any, error) {
+	return int64(c * 10), nil //nolint: mnd // tenths
+}
+
+func (c *testCelsius) UnmarshalGremlin(value any) error {
+	tenths, ok := value.(int64)
+	if !ok {
+		return fmt.Errorf("expected int64 tenths, got %T", value)
+	}
+	*c = testCelsius(tenths) / 10 //nolint: mnd // tenths
+	return nil
+}
+
+type testTyped struct {
+	gsmtypes.Vertex
+	Nickname    *string        `json:"nickname"    gremlin:"nickname"`
+	Rank        *int           `json:"rank"        gremlin:"rank"`
+	Email       sql.NullString `json:"email"       gremlin:"email"`
+	Code        testCode       `json:"code"        gremlin:"code"`
+	Temperature testCelsius    `json:"temperature" gremlin:"temperature"`
+	Seen        time.Time      `json:"seen"        gremlin:"seen"`
+	Scores      []float64      `json:"scores"      gremlin:"scores"`
+}
+
+func TestConvertUtils(t *testing.T) {
+	t.Parallel()
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	t.Run(
+		"TestPropertyValues", func(t *testing.T) {
+			t.Parallel()
+			nickname := "ada"
+			typed := testTyped{
+				Nickname:    &nickname,
+				Code:        testCode{prefix: "AB", number: 7},
+				Temperature: 21.5,
+			}
+			_, properties, err := structToMap(&typed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := map[string]any{
+				"nickname":    "ada",
+				"rank":        nil,
+				"email":       nil,
+				"code":        "AB-7",
+				"temperature": int64(215),
+			}
+			for key, value := range expected {
+				if got, ok := properties[key]; !ok || got != value {
+					t.Errorf("Expected %s to be %v, got %v", key, value, got)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestUnloadValues", func(t *testing.T) {
+			t.Parallel()
+			var typed testTyped
+			err := recursivelyUnloadIntoStruct(&typed, map[string]any{
+				"id":          int64(1),
+				"nickname":    "ada",
+				"email":       "ada@example.com",
+				"code":        "AB-7",
+				"temperature": int64(215),
+				"seen":        seen.UnixMilli(),
+				"scores":      int32(3),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := testTyped{
+				Nickname:    &[]string{"ada"}[0],
+				Email:       sql.NullString{String: "ada@example.com", Valid: true},
+				Code:        testCode{prefix: "AB", number: 7},
+				Temperature: 21.5,
+				Seen:        seen,
+				Scores:      []float64{3},
+			}
+			expected.ID = int64(1)
+			if !reflect.DeepEqual(typed, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, typed)
+			}
+		},
+	)
+	t.Run(
+		"TestUnloadNilValues", func(t *testing.T) {
+			t.Parallel()
+			nickname := "ada"
+			typed := testTyped{Nickname: &nickname, Email: sql.NullString{String: "a", Valid: true}}
+			err := recursivelyUnloadIntoStruct(&typed, map[string]any{"id": nil, "nickname": nil, "email": nil})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if typed.ID != nil || typed.Nickname != nil || typed.Email.Valid {
+				t.Errorf("Expected nil values to clear the fields, got %+v", typed)
+			}
+		},
+	)
+	t.Run(
+		"TestUnloadTimes", func(t *testing.T) {
+			t.Parallel()
+			for _, value := range []any{seen, seen.UnixMilli(), seen.Format(time.RFC3339Nano), "1714564800000"} {
+				var got time.Time
+				if err := assignValue(reflect.ValueOf(&got).Elem(), value); err != nil {
+					t.Fatalf("Expected %v (%T) to be a time, got %v", value, value, err)
+				}
+				if !got.Equal(seen) {
+					t.Errorf("Expected %v from %v, got %v", seen, value, got)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestUnloadErrors", func(t *testing.T) {
+			t.Parallel()
+			tests := map[string]map[string]any{
+				"TestNotConvertible": {"rank": "first"},
+				"TestIntAsString":    {"nickname": int64(65)},
+				"TestBadTime":        {"seen": "yesterday"},
+				"TestBadText":        {"code": "AB"},
+				"TestBadElement":     {"scores": []any{1.5, "two"}},
+			}
+			for name, properties := range tests {
+				var typed testTyped
+				err := recursivelyUnloadIntoStruct(&typed, properties)
+				if err == nil || !strings.Contains(err.Error(), "error unloading property") {
+					t.Errorf("%s: expected a descriptive error, got %v", name, err)
+				}
+			}
+		},
+	)
+}
+
+func TestConvert(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+
+	nickname := "ada"
+	typed := testTyped{
+		Nickname:    &nickname,
+		Email:       sql.NullString{String: "ada@example.com", Valid: true},
+		Code:        testCode{prefix: "AB", number: 7},
+		Temperature: 21.5,
+		Seen:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Scores:      []float64{1.5, 2},
+	}
+	if err = Create(db, &typed); err != nil {
+		t.Fatal(err)
+	}
+	found, err := Model[testTyped](db).ID(typed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Nickname == nil || *found.Nickname != nickname || found.Rank != nil || found.Email != typed.Email ||
+		found.Code != typed.Code || found.Temperature != typed.Temperature || !found.Seen.Equal(typed.Seen) {
+		t.Errorf("Expected %+v, got %+v", typed, found)
+	}
+
+	found.Nickname = nil
+	found.Email = sql.NullString{}
+	if err = Update(db, &found); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := Model[testTyped](db).ID(typed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Nickname != nil || updated.Email.Valid {
+		t.Errorf("Expected nil values to remove the properties, got %+v", updated)
+	}
+}
@@ -1,7 +1,12 @@
 package driver
 
 import (
+	"errors"
+	"fmt"
+	"maps"
 	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
@@ -12,8 +17,192 @@ func Create[T gsmtypes.VertexType](db *GremlinDriver, value *T) error {
 	return createOrUpdate(db, value)
 }
 
-func Update[T gsmtypes.VertexType](db *GremlinDriver, value *T) error {
-	return createOrUpdate(db, value)
+// Update writes the fields of an existing vertex, only the given gremlin tag names are written when fields are passed
+// created_at is never written, the stored created_at and the new last_modified are loaded back into value
+// a field tagged version is checked and incremented, see ErrStaleObject
+// ErrDuplicateKey is returned when a written natural key is already held by another vertex
+func Update[T gsmtypes.VertexType](db *GremlinDriver, value *T, fields ...string) error {
+	err := validateStructPointerWithAnonymousVertex(value)
+	if err != nil {
+		db.logger.Errorf("Validation failed: %v", err)
+		return err
+	}
+	id := (*value).GetVertexID()
+	if id == nil {
+		return errors.New("value must have an id to be updated")
+	}
+	if err = beforeUpdate(value); err != nil {
+		return err
+	}
+	// defaults are set on a copy and only copied into value for the written fields once the update applied
+	draft := reflect.New(reflect.TypeFor[T]())
+	draft.Elem().Set(reflect.ValueOf(value).Elem())
+	var missing []string
+	label, mapValue, err := structProperties(draft.Interface(), &missing)
+	if err != nil {
+		return err
+	}
+	delete(mapValue, "id")
+	delete(mapValue, gsmtypes.CreatedAt)
+	properties, cleared, err := selectUpdateFields(reflect.TypeFor[T](), mapValue, fields)
+	if err != nil {
+		return err
+	}
+	if err = checkRequiredFields(missing, fields); err != nil {
+		return err
+	}
+	if err = checkUniqueKeys(db, label, id, properties, uniqueFieldNames(reflect.TypeFor[T]())); err != nil {
+		return err
+	}
+	cardinalities, err := schemaOf(reflect.TypeFor[T]()).cardinalities(db.dbDriver)
+	if err != nil {
+		return err
+	}
+	multiValues, err := splitMultiValues(properties, cardinalities)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(value).Elem()
+	guard, err := newVersionGuard(db, rv)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	properties[gsmtypes.LastModified] = now
+
+	query := writeUpdate(guard.apply(db.g.V(id).HasLabel(label), properties), cleared, properties, multiValues)
+	results, err := await(db, query.ElementMap(gsmtypes.CreatedAt).ToList)
+	if err != nil {
+		return fmt.Errorf("failed to update %s vertex %v: %w", label, id, err)
+	}
+	if len(results) == 0 {
+		return guard.notApplied(label, id)
+	}
+	copyWrittenFields(rv, draft.Elem(), fields)
+	guard.commit()
+	if err = setStoredVertex(rv, results[0].GetInterface(), now); err != nil {
+		return err
+	}
+	return afterUpdate(value)
+}
+
+// checkRequiredFields returns ErrRequiredField when one of the missing required fields is written by the update
+// a required field is written when all fields are or when it or the nested struct holding it is one of fields
+func checkRequiredFields(missing []string, fields []string) error {
+	for _, name := range missing {
+		written := len(fields) == 0 || slices.ContainsFunc(fields, func(field string) bool {
+			return name == field || strings.HasPrefix(name, field+nestedFieldSeparator)
+		})
+		if written {
+			return fmt.Errorf("%w: %s", ErrRequiredField, name)
+		}
+	}
+	return nil
+}
+
+// copyWrittenFields copies the fields written by an update from src into dst, all of them when fields is empty
+// so the defaults set on src only show up in the fields which were stored
+func copyWrittenFields(dst reflect.Value, src reflect.Value, fields []string) {
+	if len(fields) == 0 {
+		dst.Set(src)
+		return
+	}
+	for _, field := range fields {
+		copyPropertyField(dst, src, field)
+	}
+}
+
+// copyPropertyField copies the field stored as the property name, nested names such as address.city are followed
+// into the fields of their nested struct
+func copyPropertyField(dst reflect.Value, src reflect.Value, name string) {
+	head, rest, nested := strings.Cut(name, nestedFieldSeparator)
+	field, ok := schemaOf(dst.Type()).property(head)
+	if !ok {
+		return
+	}
+	dstField, srcField := dst.FieldByIndex(field.index), src.FieldByIndex(field.index)
+	if nested && dstField.Kind() == reflect.Struct && isNestedStruct(dstField.Type()) {
+		copyPropertyField(dstField, srcField, rest)
+		return
+	}
+	dstField.Set(srcField)
+}
+
+// selectUpdateFields returns the properties named in fields, all properties when no fields are given
+// a nested struct or map field selects all of its prefixed properties, the prefixes are returned in cleared
+// so the stored properties of keys removed from the value are removed from the vertex as well
+func selectUpdateFields(
+	rt reflect.Type,
+	properties map[string]any,
+	fields []string,
+) (map[string]any, []string, error) {
+	if len(fields) == 0 {
+		return properties, clearedPrefixes(rt, properties), nil
+	}
+	flattened := flattenedFields(rt)
+	known := gremlinFieldNames(rt)
+	selected := make(map[string]any, len(fields))
+	cleared := make([]string, 0)
+	for _, field := range fields {
+		if field == "id" || field == gsmtypes.CreatedAt {
+			return nil, nil, fmt.Errorf("field %s cannot be updated", field)
+		}
+		if !hasGremlinField(known, field) {
+			return nil, nil, fmt.Errorf("field %s is not a gremlin tag of the value", field)
+		}
+		if index := slices.IndexFunc(flattened, func(tag gremlinTag) bool { return tag.name == field }); index >= 0 {
+			prefix := field + nestedFieldSeparator
+			written := prefixedProperties(properties, prefix)
+			maps.Copy(selected, written)
+			if !flattened[index].omitEmpty || len(written) > 0 {
+				cleared = append(cleared, prefix)
+			}
+			continue
+		}
+		if value, ok := properties[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected, cleared, nil
+}
+
+// createOrUpdate updates the vertex with the id of value, merges a value without an id on its natural keys
+// and adds any other value, the create hooks are only called when a vertex is added and the update hooks otherwise
+// whether a merge on natural keys adds a vertex is only known afterwards so only BeforeSave is called before it
+// clearedPrefixes returns the prefixes of the nested struct and map fields of rt whose stored properties
+// are replaced when all properties of a value are written, an omitted empty field keeps its stored properties
+func clearedPrefixes(rt reflect.Type, properties map[string]any) []string {
+	flattened := flattenedFields(rt)
+	cleared := make([]string, 0, len(flattened))
+	for _, tag := range flattened {
+		prefix := tag.name + nestedFieldSeparator
+		if tag.omitEmpty && len(prefixedProperties(properties, prefix)) == 0 {
+			continue
+		}
+		cleared = append(cleared, prefix)
+	}
+	return cleared
+}
+
+// writeUpdate appends the writes of an update of an existing vertex to query
+// the stored properties under the cleared prefixes are dropped first so nested fields and map keys
+// which are no longer in the value are removed from the vertex
+func writeUpdate(
+	query *gremlingo.GraphTraversal,
+	cleared []string,
+	properties map[string]any,
+	multiValues []multiValue,
+) *gremlingo.GraphTraversal {
+	for _, prefix := range cleared {
+		query = query.SideEffect(
+			anonymousTraversal.Properties().HasKey(gremlingo.TextP.StartingWith(prefix)).Drop(),
+		)
+	}
+	query = setProperties(query, properties)
+	for _, value := range multiValues {
+		query = value.write(query)
+	}
+	return query
 }
 
 func createOrUpdate[T gsmtypes.VertexType](db *GremlinDriver, value *T) error {
@@ -22,34 +211,121 @@ func createOrUpdate[T gsmtypes.VertexType](db *GremlinDriver, value *T) error {
 		db.logger.Errorf("Validation failed: %v", err)
 		return err
 	}
+	keys := uniqueFieldNames(reflect.TypeFor[T]())
+	switch {
+	case (*value).GetVertexID() != nil:
+		if err = beforeUpdate(value); err != nil {
+			return err
+		}
+		if err = mergeVertex(db, value); err != nil {
+			return err
+		}
+		return afterUpdate(value)
+	case len(keys) > 0:
+		// values with natural keys are merged on them so creating the same value twice does not duplicate it
+		if err = callHook(value, gsmtypes.BeforeSaver.BeforeSave); err != nil {
+			return err
+		}
+		created, upsertErr := upsert(db, value, keys)
+		if upsertErr != nil {
+			return upsertErr
+		}
+		if created {
+			return afterCreate(value)
+		}
+		return afterUpdate(value)
+	default:
+		if err = beforeCreate(value); err != nil {
+			return err
+		}
+		if err = mergeVertex(db, value); err != nil {
+			return err
+		}
+		return afterCreate(value)
+	}
+}
+
+// mergeVertex updates the stored vertex with the id of the value or adds the value when it has none
+// the update is checked and increments the version like Update, see versionGuard
+func mergeVertex[T gsmtypes.VertexType](db *GremlinDriver, value *T) error {
 	now := time.Now().UTC()
-	label, mapValue, err := structToMap(value)
+	merge, err := newValueMerge(db, value, now)
 	if err != nil {
 		return err
 	}
-	id := mapValue["id"]
-	delete(mapValue, "id")
-	mapValue[gsmtypes.LastModified] = now
-	var query *gremlingo.GraphTraversal
-	newMap := make(map[any]any, len(mapValue))
-	if id == nil {
-		mapValue[gsmtypes.CreatedAt] = now
-		for k, v := range mapValue {
-			newMap[k] = v
+	guard := &versionGuard{}
+	if merge.id != nil {
+		keys := uniqueFieldNames(reflect.TypeFor[T]())
+		if err = checkUniqueKeys(db, merge.label, merge.id, merge.onMatch, keys); err != nil {
+			return err
 		}
-		newMap[gremlingo.T.Label] = label
-		query = db.g.MergeV(newMap)
-	} else {
-		query = db.g.MergeV(map[any]any{gremlingo.T.Id: id})
+		if guard, err = newVersionGuard(db, reflect.ValueOf(value).Elem()); err != nil {
+			return err
+		}
+		merge.guard = guard
 	}
-	query.Option(gremlingo.Merge.OnMatch, mapValue)
-	vertexID, err := query.Id().Next()
+	results, err := await(db, merge.traversal(db, nil).ElementMap(gsmtypes.CreatedAt).ToList)
 	if err != nil {
 		return err
 	}
-	reflect.ValueOf(value).Elem().FieldByName("ID").Set(reflect.ValueOf(vertexID.GetInterface()))
-	reflectNow := reflect.ValueOf(now)
-	reflect.ValueOf(value).Elem().FieldByName("LastModified").Set(reflectNow)
-	reflect.ValueOf(value).Elem().FieldByName("CreatedAt").Set(reflectNow)
+	if len(results) == 0 {
+		return guard.notApplied(merge.label, merge.id)
+	}
+	if err = setStoredVertex(reflect.ValueOf(value).Elem(), results[0].GetInterface(), now); err != nil {
+		return err
+	}
+	guard.commit()
+	return nil
+}
+
+// newValueMerge builds the merge adding the value, or updating the stored vertex with its id
+// an update replaces the stored properties of the nested struct and map fields like Update, see clearedPrefixes
+func newValueMerge[T gsmtypes.VertexType](db *GremlinDriver, value *T, now time.Time) (*vertexMerge, error) {
+	label, mapValue, err := structToMap(value)
+	if err != nil {
+		return nil, err
+	}
+	id := mapValue["id"]
+	delete(mapValue, "id")
+	cardinalities, err := schemaOf(reflect.TypeFor[T]()).cardinalities(db.dbDriver)
+	if err != nil {
+		return nil, err
+	}
+	merge, err := newVertexMerge(id, label, mapValue, nil, cardinalities, now)
+	if err != nil {
+		return nil, err
+	}
+	if id != nil {
+		merge.prefixes = clearedPrefixes(reflect.TypeFor[T](), mapValue)
+	}
+	return merge, nil
+}
+
+// setProperties writes the properties in key order with single cardinality
+// nil pointers and null values remove the stored property
+func setProperties(query *gremlingo.GraphTraversal, properties map[string]any) *gremlingo.GraphTraversal {
+	for _, key := range slices.Sorted(maps.Keys(properties)) {
+		if properties[key] == nil {
+			query = query.SideEffect(anonymousTraversal.Properties(key).Drop())
+			continue
+		}
+		query = query.Property(cardinality.Single, key, properties[key])
+	}
+	return query
+}
+
+// setStoredVertex writes the id and created_at of the element map of a stored vertex and lastModified into rv
+func setStoredVertex(rv reflect.Value, stored any, lastModified time.Time) error {
+	element, ok := stored.(map[any]any)
+	if !ok {
+		return fmt.Errorf("unexpected stored vertex %T", stored)
+	}
+	if id, found := element["id"]; found {
+		rv.FieldByName("ID").Set(reflect.ValueOf(id))
+	}
+	rv.FieldByName("LastModified").Set(reflect.ValueOf(lastModified))
+	if createdAt, found := element[gsmtypes.CreatedAt].(time.Time); found {
+		rv.FieldByName("CreatedAt").Set(reflect.ValueOf(createdAt))
+	}
 	return nil
 }
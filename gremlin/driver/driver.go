@@ -1,7 +1,7 @@
 package driver
 
 import (
-	"fmt"
+	"context"
 
 	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
 	"github.com/charmbracelet/log"
@@ -20,8 +20,20 @@ const (
 type GremlinDriver struct {
 	remoteConn *gremlingo.DriverRemoteConnection
 	g          *gremlingo.GraphTraversalSource
-	logger     *log.Logger
-	dbDriver   DatabaseDriver
+	// source is g without the evaluationTimeout option of WithContext, nil means g has no such option
+	source   *gremlingo.GraphTraversalSource
+	logger   *log.Logger
+	dbDriver DatabaseDriver
+	// ctx is set by WithContext, nil means context.Background
+	ctx context.Context
+	// transactions is shared by every copy of the driver using the same connection
+	transactions  *transactionSupport
+	inTransaction bool
+	batchSize     int
+	// optimisticLocking makes Update check last_modified for types without a version field
+	optimisticLocking bool
+	// registry is set by WithRegistry, nil means every model can be queried
+	registry *Registry
 }
 
 type QueryOpts struct {
@@ -36,19 +48,32 @@ func g(remoteConnection *gremlingo.DriverRemoteConnection) *gremlingo.GraphTrave
 	return gremlingo.Traversal_().WithRemote(remoteConnection)
 }
 
-func Open(url string, dbDriver DatabaseDriver) (*GremlinDriver, error) {
+// Open connects to the gremlin server at url, options are applied in order on top of DefaultOptions
+// e.g. Open(url, driver.Neptune, driver.WithBasicAuth(user, password), driver.WithPoolSize(8))
+func Open(url string, opts ...Option) (*GremlinDriver, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, err
+	}
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
 	driverLogger := appLogger.InitializeLogger()
-	driverLogger.Infof("Opening driver with url: %s/gremlin", url)
-	remote, err := gremlingo.NewDriverRemoteConnection(fmt.Sprintf("%s/gremlin", url))
+	driverLogger.Infof("Opening driver with url: %s%s", url, options.Path)
+	remote, err := gremlingo.NewDriverRemoteConnection(url+options.Path, options.configure)
 	if err != nil {
 		return nil, err
 	}
 
 	driver := &GremlinDriver{
-		g:          g(remote),
-		remoteConn: remote,
-		logger:     driverLogger,
-		dbDriver:   dbDriver,
+		g:                 g(remote),
+		remoteConn:        remote,
+		logger:            driverLogger,
+		dbDriver:          options.DatabaseDriver,
+		transactions:      &transactionSupport{},
+		batchSize:         options.BatchSize,
+		optimisticLocking: options.OptimisticLocking,
+		registry:          options.Registry,
 	}
 	return driver, nil
 }
@@ -79,6 +104,11 @@ func Model[T gsmtypes.VertexType](driver *GremlinDriver) *Query[T] {
 	return NewQuery[T](driver)
 }
 
+// EdgeModel returns a new edge query builder for the specified type
+func EdgeModel[E gsmtypes.EdgeType](driver *GremlinDriver) *EdgeQuery[E] {
+	return NewEdgeQuery[E](driver)
+}
+
 // Where is a convenience method that creates a new query with a condition
 func Where[T gsmtypes.VertexType](
 	driver *GremlinDriver,
@@ -3,8 +3,10 @@ package driver
 import (
 	"testing"
 
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
 	"github.com/jbrusegaard/graph-struct-manager/comparator"
 	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+	appLogger "github.com/jbrusegaard/graph-struct-manager/log"
 )
 
 type testVertex struct {
@@ -14,6 +16,25 @@ type testVertex struct {
 
 const DbURL = "ws://localhost:8182"
 
+// newOfflineDriver returns a driver without a remote connection which can be used to build traversals in tests
+func newOfflineDriver() *GremlinDriver {
+	return &GremlinDriver{
+		g:        gremlingo.NewDefaultGraphTraversalSource(),
+		logger:   appLogger.InitializeLogger(),
+		dbDriver: Gremlin,
+	}
+}
+
+// translate converts a traversal into its gremlin groovy string so the built steps can be asserted on
+func translate(t *testing.T, traversal *gremlingo.GraphTraversal) string {
+	t.Helper()
+	query, err := gremlingo.NewTranslator("g").Translate(traversal.Bytecode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query
+}
+
 func TestDriverConnections(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -0,0 +1,133 @@
+package driver
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// CreateEdge creates a new edge going out of from and into to
+// the edge label is determined the same way as vertex labels and the generated id is written back into the struct
+// an edge which already has an id is rejected, use UpdateEdge or SaveEdge for it
+func CreateEdge[E gsmtypes.EdgeType](
+	db *GremlinDriver,
+	edge *E,
+	from gsmtypes.VertexType,
+	to gsmtypes.VertexType,
+) error {
+	return createOrUpdateEdge(db, edge, from, to, true)
+}
+
+// UpdateEdge updates the properties of an existing edge, the edge must have an id
+func UpdateEdge[E gsmtypes.EdgeType](db *GremlinDriver, edge *E) error {
+	return createOrUpdateEdge(db, edge, nil, nil, false)
+}
+
+// SaveEdge creates the edge if it has no id yet otherwise it updates the properties of the existing edge
+// from and to are only used when the edge is created
+func SaveEdge[E gsmtypes.EdgeType](
+	db *GremlinDriver,
+	edge *E,
+	from gsmtypes.VertexType,
+	to gsmtypes.VertexType,
+) error {
+	if (*edge).GetEdgeID() == nil {
+		return CreateEdge(db, edge, from, to)
+	}
+	return UpdateEdge(db, edge)
+}
+
+// DeleteEdge drops the edge from the graph
+func DeleteEdge[E gsmtypes.EdgeType](db *GremlinDriver, edge *E) error {
+	err := validateStructPointerWithAnonymousEdge(edge)
+	if err != nil {
+		db.logger.Errorf("Validation failed: %v", err)
+		return err
+	}
+	id := (*edge).GetEdgeID()
+	if id == nil {
+		return errors.New("edge must have an id to be deleted")
+	}
+	return awaitIterate(db, db.g.E(id).Drop().Iterate)
+}
+
+// createOrUpdateEdge adds the edge when create is set and updates the edge with the id of value otherwise
+func createOrUpdateEdge[E gsmtypes.EdgeType](
+	db *GremlinDriver,
+	value *E,
+	from gsmtypes.VertexType,
+	to gsmtypes.VertexType,
+	create bool,
+) error {
+	err := validateStructPointerWithAnonymousEdge(value)
+	if err != nil {
+		db.logger.Errorf("Validation failed: %v", err)
+		return err
+	}
+	now := time.Now().UTC()
+	label, mapValue, err := structToMap(value)
+	if err != nil {
+		return err
+	}
+	id := mapValue["id"]
+	if create && id != nil {
+		return errors.New("edge already has an id, use UpdateEdge or SaveEdge to update it")
+	}
+	if !create && id == nil {
+		return errors.New("edge must have an id to be updated")
+	}
+	delete(mapValue, "id")
+	mapValue[gsmtypes.LastModified] = now.Format(time.RFC3339Nano)
+	var query *gremlingo.GraphTraversal
+	if create {
+		query, err = addEdgeTraversal(db, label, from, to)
+		if err != nil {
+			return err
+		}
+		mapValue[gsmtypes.CreatedAt] = now.UnixMilli()
+	} else {
+		// created_at is only ever set when the edge is created
+		delete(mapValue, gsmtypes.CreatedAt)
+		query = db.g.E(id)
+	}
+	for _, key := range slices.Sorted(maps.Keys(mapValue)) {
+		if mapValue[key] == nil {
+			// nil pointers and null values remove the stored property
+			query = query.SideEffect(anonymousTraversal.Properties(key).Drop())
+			continue
+		}
+		query = query.Property(key, mapValue[key])
+	}
+	edgeID, err := await(db, query.Id().Next)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(value).Elem()
+	rv.FieldByName("ID").Set(reflect.ValueOf(edgeID.GetInterface()))
+	rv.FieldByName("LastModified").SetString(mapValue[gsmtypes.LastModified].(string)) //nolint:errcheck // set above
+	if create {
+		rv.FieldByName("CreatedAt").SetInt(now.UnixMilli())
+	}
+	return nil
+}
+
+// addEdgeTraversal builds the traversal adding an edge with label going out of from and into to
+func addEdgeTraversal(
+	db *GremlinDriver,
+	label string,
+	from gsmtypes.VertexType,
+	to gsmtypes.VertexType,
+) (*gremlingo.GraphTraversal, error) {
+	if from == nil || from.GetVertexID() == nil {
+		return nil, errors.New("out vertex must have an id to create an edge")
+	}
+	if to == nil || to.GetVertexID() == nil {
+		return nil, errors.New("in vertex must have an id to create an edge")
+	}
+	return db.g.V(from.GetVertexID()).AddE(label).To(anonymousTraversal.V(to.GetVertexID())), nil
+}
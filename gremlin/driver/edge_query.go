@@ -0,0 +1,248 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// EdgeQuery represents a chainable query builder for edges
+type EdgeQuery[E gsmtypes.EdgeType] struct {
+	db          *GremlinDriver
+	conditions  []*QueryCondition
+	label       string
+	outIDs      []any
+	inIDs       []any
+	limit       *int
+	offset      *int
+	orderBy     []*OrderCondition
+	debugString *strings.Builder
+	// errs holds the problems found while the query was built, they are returned before anything is sent
+	errs []error
+}
+
+// NewEdgeQuery creates a new query builder for edge type E
+func NewEdgeQuery[E gsmtypes.EdgeType](db *GremlinDriver) *EdgeQuery[E] {
+	var e E
+	label := getLabelFromEdge(e)
+	queryAsString := strings.Builder{}
+	queryAsString.WriteString("E()")
+	if label != "" {
+		queryAsString.WriteString(".HasLabel(")
+		queryAsString.WriteString(label)
+		queryAsString.WriteString(")")
+	}
+	return &EdgeQuery[E]{
+		db:          db,
+		debugString: &queryAsString,
+		conditions:  make([]*QueryCondition, 0),
+		label:       label,
+	}
+}
+
+// From narrows the query to edges going out of the vertices with the given ids
+func (q *EdgeQuery[E]) From(ids ...any) *EdgeQuery[E] {
+	q.writeDebugString(fmt.Sprintf(".Where(OutV().HasId(%v))", ids))
+	q.outIDs = append(q.outIDs, ids...)
+	return q
+}
+
+// To narrows the query to edges going into the vertices with the given ids
+func (q *EdgeQuery[E]) To(ids ...any) *EdgeQuery[E] {
+	q.writeDebugString(fmt.Sprintf(".Where(InV().HasId(%v))", ids))
+	q.inIDs = append(q.inIDs, ids...)
+	return q
+}
+
+// Where adds a condition to the query
+func (q *EdgeQuery[E]) Where(field string, operator comparator.Comparator, value any) *EdgeQuery[E] {
+	queryCondition := Cond(field, operator, value)
+	if err := validateCondition(queryCondition, gremlinFieldNames(reflect.TypeFor[E]())); err != nil {
+		q.errs = append(q.errs, err)
+	}
+	q.writeDebugString(queryCondition.String())
+	q.conditions = append(q.conditions, queryCondition)
+	return q
+}
+
+// WithContext binds the query to ctx, see GremlinDriver.WithContext
+func (q *EdgeQuery[E]) WithContext(ctx context.Context) *EdgeQuery[E] {
+	q.db = q.db.WithContext(ctx)
+	return q
+}
+
+// Limit sets the maximum number of results
+func (q *EdgeQuery[E]) Limit(limit int) *EdgeQuery[E] {
+	q.writeDebugString(".Limit(")
+	q.writeDebugString(strconv.Itoa(limit))
+	q.writeDebugString(")")
+	q.limit = &limit
+	return q
+}
+
+// Offset sets the number of results to skip
+func (q *EdgeQuery[E]) Offset(offset int) *EdgeQuery[E] {
+	q.writeDebugString(".Skip(")
+	q.writeDebugString(strconv.Itoa(offset))
+	q.writeDebugString(")")
+	q.offset = &offset
+	return q
+}
+
+// OrderBy adds an order key to the query, calling it again adds a secondary key
+// ordering by "id" orders by the edge id
+func (q *EdgeQuery[E]) OrderBy(field string, order GremlinOrder) *EdgeQuery[E] {
+	q.writeDebugString(".OrderBy(" + field + ", " + order.String() + ")")
+	if err := validateOrderField(field, gremlinFieldNames(reflect.TypeFor[E]())); err != nil {
+		q.errs = append(q.errs, err)
+	}
+	q.orderBy = append(q.orderBy, &OrderCondition{field: field, order: order})
+	return q
+}
+
+// Find executes the query and returns all matching edges
+func (q *EdgeQuery[E]) Find() ([]E, error) {
+	q.writeDebugString(".ToList()")
+	query, err := q.build()
+	if err != nil {
+		return nil, err
+	}
+	queryResults, err := await(q.db, ToMapTraversal(query, nil, true).ToList)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]E, 0, len(queryResults))
+	for _, result := range queryResults {
+		var e E
+		err = UnloadGremlinResultIntoStruct(&e, result)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, e)
+	}
+	return results, nil
+}
+
+// Take executes the query and returns the first matching edge
+func (q *EdgeQuery[E]) Take() (E, error) {
+	q.writeDebugString(".Next()")
+	var e E
+	query, err := q.build()
+	if err != nil {
+		return e, err
+	}
+	result, err := await(q.db, ToMapTraversal(query, nil, true).Next)
+	if err != nil {
+		return e, err
+	}
+	err = UnloadGremlinResultIntoStruct(&e, result)
+	return e, err
+}
+
+// Count returns the number of matching edges
+func (q *EdgeQuery[E]) Count() (int, error) {
+	q.writeDebugString(".Count()")
+	query, err := q.build()
+	if err != nil {
+		return 0, err
+	}
+	result, err := await(q.db, query.Count().Next)
+	if err != nil {
+		return 0, err
+	}
+	return result.GetInt()
+}
+
+// Delete drops all matching edges, the vertices they connect are left untouched
+func (q *EdgeQuery[E]) Delete() error {
+	q.writeDebugString(".Drop().Iterate()")
+	query, err := q.build()
+	if err != nil {
+		return err
+	}
+	return awaitIterate(q.db, query.Drop().Iterate)
+}
+
+// Update sets a property on all matching edges
+func (q *EdgeQuery[E]) Update(propertyName string, value any) error {
+	// the error is not kept on the query so it can still be used after an invalid update
+	if _, _, err := getStructFieldNameAndType[E](propertyName); err != nil {
+		return newValidationError(fmt.Errorf("propertyName not found in gremlin struct tags: %s", propertyName))
+	}
+	q.writeDebugString(fmt.Sprintf(".Property(%s, %v)", propertyName, value))
+	query, err := q.build()
+	if err != nil {
+		return err
+	}
+	query = query.
+		Property(gsmtypes.LastModified, time.Now().UTC().Format(time.RFC3339Nano)).
+		Property(propertyName, value)
+	return awaitIterate(q.db, query.Iterate)
+}
+
+// writeDebugString writes a string to the debug string if GSM_DEBUG is set to true
+func (q *EdgeQuery[E]) writeDebugString(s string) {
+	if os.Getenv("GSM_DEBUG") == "true" {
+		q.debugString.WriteString(s)
+	}
+}
+
+// BuildQuery constructs the Gremlin traversal from the query conditions
+// when the query is invalid the errors are logged and the traversal ends in limit(0) so it matches nothing,
+// e.g. a misspelt field followed by Drop() drops nothing instead of every element of the label
+// the terminal operations such as Find and Delete return a ValidationError instead of running the query
+func (q *EdgeQuery[E]) BuildQuery() *gremlingo.GraphTraversal {
+	query, err := q.build()
+	if err != nil {
+		q.db.logger.Errorf("Failed to build query: %v", err)
+		return query.Limit(0)
+	}
+	return query
+}
+
+// build logs the debug string and constructs the Gremlin traversal
+// the traversal is always returned, problems recorded while building the query are reported in the error
+func (q *EdgeQuery[E]) build() (*gremlingo.GraphTraversal, error) {
+	if os.Getenv("GSM_DEBUG") == "true" {
+		q.db.logger.Infof("Running Query: %s", q.debugString.String())
+		q.debugString.Reset()
+	}
+	query := q.db.g.E()
+	if q.label != "" {
+		query = query.HasLabel(q.label)
+	}
+	if len(q.outIDs) > 0 {
+		query = query.Where(anonymousTraversal.OutV().HasId(q.outIDs...))
+	}
+	if len(q.inIDs) > 0 {
+		query = query.Where(anonymousTraversal.InV().HasId(q.inIDs...))
+	}
+
+	// conditions are validated when added so compile errors are only reported when nothing was recorded
+	err := addQueryConditions(query, q.conditions)
+	if len(q.errs) > 0 {
+		err = errors.Join(q.errs...)
+	}
+	addOrderCondition(query, q.orderBy)
+
+	if q.offset != nil {
+		query = query.Skip(*q.offset)
+	}
+	if q.limit != nil {
+		query = query.Limit(*q.limit)
+	}
+	if err != nil {
+		return query, newValidationError(err)
+	}
+	return query, nil
+}
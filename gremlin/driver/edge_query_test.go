@@ -0,0 +1,136 @@
+package driver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+)
+
+func TestEdgeQueryBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	tests := []struct {
+		testName string
+		query    *EdgeQuery[testEdge]
+		expected string
+	}{
+		{
+			testName: "TestEdgeQueryLabel",
+			query:    EdgeModel[testEdge](db),
+			expected: "g.E().hasLabel('test_edge')",
+		},
+		{
+			testName: "TestEdgeQueryFromTo",
+			query:    EdgeModel[testEdge](db).From(1).To(2, 3),
+			expected: "g.E().hasLabel('test_edge').where(outV().hasId(1)).where(inV().hasId(2,3))",
+		},
+		{
+			testName: "TestEdgeQueryWhereOrderLimitOffset",
+			query: EdgeModel[testEdge](db).
+				Where("role", comparator.EQ, "owner").
+				OrderBy("created_at", Desc).
+				OrderBy("id", Asc).
+				Offset(1).
+				Limit(2),
+			expected: "g.E().hasLabel('test_edge').has('role','owner')" +
+				".order().by('created_at',desc).by(id,asc).skip(1).limit(2)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.testName, func(t *testing.T) {
+				t.Parallel()
+				if got := translate(t, tt.query.BuildQuery()); got != tt.expected {
+					t.Errorf("Expected %s, got %s", tt.expected, got)
+				}
+			},
+		)
+	}
+	t.Run(
+		"TestEdgeQueryUpdateBadInput", func(t *testing.T) {
+			t.Parallel()
+			query := EdgeModel[testEdge](db)
+			var validationErr *ValidationError
+			if err := query.Update("badField", "badValue"); !errors.As(err, &validationErr) {
+				t.Errorf("Expected ValidationError, got %v", err)
+			}
+			if _, err := query.build(); err != nil {
+				t.Errorf("Expected a failed update not to break the query, got %v", err)
+			}
+		},
+	)
+}
+
+func TestEdgeQuery(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	seedEdges := func(t *testing.T) (testVertex, testVertex) {
+		t.Helper()
+		from := testVertex{Name: "from"}
+		to := testVertex{Name: "to"}
+		if err = Create(db, &from); err != nil {
+			t.Fatal(err)
+		}
+		if err = Create(db, &to); err != nil {
+			t.Fatal(err)
+		}
+		for _, role := range []string{"owner", "admin"} {
+			if err = CreateEdge(db, &testEdge{Role: role}, from, to); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return from, to
+	}
+
+	t.Run(
+		"TestEdgeQueryFind", func(t *testing.T) {
+			t.Cleanup(cleanDB)
+			from, _ := seedEdges(t)
+			edges, err := EdgeModel[testEdge](db).From(from.ID).OrderBy("role", Asc).Find()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(edges) != 2 {
+				t.Fatalf("Expected 2 edges, got %d", len(edges))
+			}
+			if edges[0].Role != "admin" || edges[1].Role != "owner" {
+				t.Errorf("Expected admin and owner, got %s and %s", edges[0].Role, edges[1].Role)
+			}
+			if edges[0].ID == nil {
+				t.Error("Expected edge id to be unloaded")
+			}
+		},
+	)
+	t.Run(
+		"TestEdgeQueryUpdateAndDelete", func(t *testing.T) {
+			t.Cleanup(cleanDB)
+			_, to := seedEdges(t)
+			err := EdgeModel[testEdge](db).Where("role", comparator.EQ, "owner").Update("role", "viewer")
+			if err != nil {
+				t.Fatal(err)
+			}
+			edge, err := EdgeModel[testEdge](db).To(to.ID).Where("role", comparator.EQ, "viewer").Take()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if edge.Role != "viewer" {
+				t.Errorf("Expected viewer, got %s", edge.Role)
+			}
+			if err = EdgeModel[testEdge](db).Where("role", comparator.EQ, "viewer").Delete(); err != nil {
+				t.Fatal(err)
+			}
+			count, err := EdgeModel[testEdge](db).Count()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != 1 {
+				t.Errorf("Expected 1 edge, got %d", count)
+			}
+		},
+	)
+}
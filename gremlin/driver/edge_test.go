@@ -0,0 +1,154 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testEdge struct {
+	gsmtypes.Edge
+	Role string `json:"role" gremlin:"role"`
+}
+
+type testEdgeWithoutAnonymousEdge struct {
+	Role string `json:"role" gremlin:"role"`
+}
+
+func (e testEdgeWithoutAnonymousEdge) GetEdgeID() any              { return nil }
+func (e testEdgeWithoutAnonymousEdge) GetEdgeLastModified() string { return "" }
+func (e testEdgeWithoutAnonymousEdge) GetEdgeCreatedAt() int64     { return 0 }
+func (e testEdgeWithoutAnonymousEdge) Label() string               { return "" }
+
+func TestEdgeValidation(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	from := testVertex{Vertex: gsmtypes.Vertex{ID: 1}}
+	to := testVertex{Vertex: gsmtypes.Vertex{ID: 2}}
+
+	t.Run(
+		"TestCreateEdgeWithoutAnonymousEdge", func(t *testing.T) {
+			t.Parallel()
+			err := CreateEdge(db, &testEdgeWithoutAnonymousEdge{}, from, to)
+			if err == nil {
+				t.Error("Expected error for struct without anonymous edge")
+			}
+		},
+	)
+	t.Run(
+		"TestCreateEdgeWithoutOutVertexID", func(t *testing.T) {
+			t.Parallel()
+			err := CreateEdge(db, &testEdge{}, testVertex{}, to)
+			if err == nil {
+				t.Error("Expected error for out vertex without id")
+			}
+		},
+	)
+	t.Run(
+		"TestCreateEdgeWithoutInVertexID", func(t *testing.T) {
+			t.Parallel()
+			err := CreateEdge(db, &testEdge{}, from, nil)
+			if err == nil {
+				t.Error("Expected error for missing in vertex")
+			}
+		},
+	)
+	t.Run(
+		"TestCreateEdgeWithID", func(t *testing.T) {
+			t.Parallel()
+			err := CreateEdge(db, &testEdge{Edge: gsmtypes.Edge{ID: 3}}, from, to)
+			if err == nil || !strings.Contains(err.Error(), "already has an id") {
+				t.Errorf("Expected error creating edge with id, got %v", err)
+			}
+		},
+	)
+	t.Run(
+		"TestUpdateEdgeWithoutID", func(t *testing.T) {
+			t.Parallel()
+			err := UpdateEdge(db, &testEdge{})
+			if err == nil || !strings.Contains(err.Error(), "must have an id to be updated") {
+				t.Errorf("Expected error updating edge without id, got %v", err)
+			}
+		},
+	)
+	t.Run(
+		"TestDeleteEdgeWithoutID", func(t *testing.T) {
+			t.Parallel()
+			err := DeleteEdge(db, &testEdge{})
+			if err == nil {
+				t.Error("Expected error deleting edge without id")
+			}
+		},
+	)
+	t.Run(
+		"TestAddEdgeTraversal", func(t *testing.T) {
+			t.Parallel()
+			query, err := addEdgeTraversal(db, "test_edge", from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "g.V(1).addE('test_edge').to(V(2))"
+			if got := translate(t, query); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		},
+	)
+}
+
+func TestEdge(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run(
+		"TestCreateSaveDeleteEdge", func(t *testing.T) {
+			t.Cleanup(cleanDB)
+			from := testVertex{Name: "from"}
+			to := testVertex{Name: "to"}
+			if err = Create(db, &from); err != nil {
+				t.Fatal(err)
+			}
+			if err = Create(db, &to); err != nil {
+				t.Fatal(err)
+			}
+			edge := testEdge{Role: "owner"}
+			if err = CreateEdge(db, &edge, from, to); err != nil {
+				t.Fatal(err)
+			}
+			if edge.ID == nil {
+				t.Fatal("Expected edge id to be set")
+			}
+			if edge.CreatedAt == 0 || edge.LastModified == "" {
+				t.Error("Expected edge timestamps to be set")
+			}
+			createdAt := edge.CreatedAt
+			edge.Role = "admin"
+			if err = SaveEdge(db, &edge, from, to); err != nil {
+				t.Fatal(err)
+			}
+			if edge.CreatedAt != createdAt {
+				t.Errorf("Expected created at %d to be kept, got %d", createdAt, edge.CreatedAt)
+			}
+			role, err := db.g.E(edge.ID).Values("role").Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if role.GetString() != "admin" {
+				t.Errorf("Expected role admin, got %s", role.GetString())
+			}
+			if err = DeleteEdge(db, &edge); err != nil {
+				t.Fatal(err)
+			}
+			count, err := db.g.E(edge.ID).Count().Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if num, _ := count.GetInt(); num != 0 {
+				t.Errorf("Expected edge to be deleted, found %d", num)
+			}
+		},
+	)
+}
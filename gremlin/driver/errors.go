@@ -0,0 +1,100 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrQueryAborted is returned when the context of the driver is done before the server answered
+// the error wraps the error of the context so errors.Is(err, context.Canceled) keeps working
+var ErrQueryAborted = errors.New("gremlin query aborted")
+
+// ErrTransactionsNotSupported is returned by Transaction when the graph, e.g. TinkerGraph, does not support transactions
+var ErrTransactionsNotSupported = errors.New("graph does not support transactions")
+
+// ErrNestedTransaction is returned by Transaction when it is called on the driver of a running transaction
+var ErrNestedTransaction = errors.New("transaction already in progress")
+
+// ErrStaleObject is returned by Update and Save when optimistic locking is used
+// and the vertex was changed or deleted by another writer since the value was loaded
+var ErrStaleObject = errors.New("stale object")
+
+// ErrRequiredField is returned when a value is written while a field tagged required holds its zero value
+var ErrRequiredField = errors.New("required field is empty")
+
+// ErrDuplicateKey is returned when an update would give a vertex the natural key of another vertex with its label
+var ErrDuplicateKey = errors.New("natural key already used")
+
+// ErrLabelCollision is returned by Registry.Register when two models of the same kind have the same label
+var ErrLabelCollision = errors.New("label collision")
+
+// ErrModelNotRegistered is returned by the queries of a driver opened WithRegistry for models which are not registered
+var ErrModelNotRegistered = errors.New("model not registered")
+
+// ValidationError is returned by the terminal query operations when the query is invalid
+// e.g. a comparator is unknown, a value does not fit its comparator or a field is not a gremlin tag of the model
+// nothing is sent to the server when a ValidationError is returned
+type ValidationError struct {
+	Errs []error
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		messages = append(messages, err.Error())
+	}
+	return "invalid query: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return e.Errs
+}
+
+// newValidationError wraps err into a ValidationError, errors joined with errors.Join are flattened
+func newValidationError(err error) *ValidationError {
+	return &ValidationError{Errs: flattenErrors(err)}
+}
+
+// flattenErrors splits errors joined with errors.Join into their parts
+// errors wrapped with fmt.Errorf are kept as they are so their context is not lost
+func flattenErrors(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error }) //nolint: errorlint // only the error itself is split
+	if !ok {
+		return []error{err}
+	}
+	errs := make([]error, 0)
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(e)...)
+	}
+	return errs
+}
+
+func newAbortedError(ctxErr error) error {
+	return fmt.Errorf("%w: %w", ErrQueryAborted, ctxErr)
+}
+
+// BatchError is returned by CreateMany and SaveMany when some of the values could not be written
+// Errs is keyed by the index of the value, every value which is not in Errs was written
+type BatchError struct {
+	Total int
+	Errs  map[int]error
+}
+
+func (e *BatchError) Error() string {
+	messages := make([]string, 0, len(e.Errs))
+	for _, index := range slices.Sorted(maps.Keys(e.Errs)) {
+		messages = append(messages, fmt.Sprintf("item %d: %v", index, e.Errs[index]))
+	}
+	return fmt.Sprintf("batch failed for %d of %d items: %s", len(e.Errs), e.Total, strings.Join(messages, "; "))
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errs))
+	for _, index := range slices.Sorted(maps.Keys(e.Errs)) {
+		errs = append(errs, e.Errs[index])
+	}
+	return errs
+}
@@ -0,0 +1,54 @@
+package driver
+
+import (
+	"reflect"
+
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// callHook calls hook when value implements the hook interface H
+func callHook[H any](value any, hook func(H) error) error {
+	if h, ok := value.(H); ok {
+		return hook(h)
+	}
+	return nil
+}
+
+func beforeCreate(value any) error {
+	if err := callHook(value, gsmtypes.BeforeSaver.BeforeSave); err != nil {
+		return err
+	}
+	return callHook(value, gsmtypes.BeforeCreator.BeforeCreate)
+}
+
+func afterCreate(value any) error {
+	if err := callHook(value, gsmtypes.AfterCreator.AfterCreate); err != nil {
+		return err
+	}
+	return callHook(value, gsmtypes.AfterSaver.AfterSave)
+}
+
+func beforeUpdate(value any) error {
+	if err := callHook(value, gsmtypes.BeforeSaver.BeforeSave); err != nil {
+		return err
+	}
+	return callHook(value, gsmtypes.BeforeUpdater.BeforeUpdate)
+}
+
+func afterUpdate(value any) error {
+	if err := callHook(value, gsmtypes.AfterUpdater.AfterUpdate); err != nil {
+		return err
+	}
+	return callHook(value, gsmtypes.AfterSaver.AfterSave)
+}
+
+func afterFind(value any) error {
+	return callHook(value, gsmtypes.AfterFinder.AfterFind)
+}
+
+// hasDeleteHooks reports whether *T implements a delete hook, the matched vertices only have to be loaded when it does
+func hasDeleteHooks[T any]() bool {
+	pointerType := reflect.TypeFor[*T]()
+	return pointerType.Implements(reflect.TypeFor[gsmtypes.BeforeDeleter]()) ||
+		pointerType.Implements(reflect.TypeFor[gsmtypes.AfterDeleter]())
+}
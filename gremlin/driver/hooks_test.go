@@ -0,0 +1,263 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+var errHookRejected = errors.New("rejected by hook")
+
+type testHooked struct {
+	gsmtypes.Vertex
+	Name   string `json:"name"   gremlin:"name"`
+	Status string `json:"status" gremlin:"status"`
+	calls  []string
+}
+
+func (h *testHooked) BeforeSave() error {
+	h.calls = append(h.calls, "BeforeSave")
+	if h.Name == "" {
+		return errHookRejected
+	}
+	return nil
+}
+
+func (h *testHooked) BeforeCreate() error {
+	h.calls = append(h.calls, "BeforeCreate")
+	if h.Status == "" {
+		h.Status = "new"
+	}
+	return nil
+}
+
+func (h *testHooked) AfterCreate() error {
+	h.calls = append(h.calls, "AfterCreate")
+	return nil
+}
+
+func (h *testHooked) BeforeUpdate() error {
+	h.calls = append(h.calls, "BeforeUpdate")
+	return nil
+}
+
+func (h *testHooked) AfterSave() error {
+	h.calls = append(h.calls, "AfterSave")
+	return nil
+}
+
+func (h *testHooked) AfterFind() error {
+	h.calls = append(h.calls, "AfterFind")
+	return nil
+}
+
+func (h *testHooked) BeforeDelete() error {
+	if h.Status == "locked" {
+		return errHookRejected
+	}
+	return nil
+}
+
+// testHookedUser records its create and update hooks, it is merged on its unique email
+type testHookedUser struct {
+	gsmtypes.Vertex
+	Email string `json:"email" gremlin:"email,unique"`
+	calls []string
+}
+
+func (u *testHookedUser) BeforeCreate() error {
+	u.calls = append(u.calls, "BeforeCreate")
+	return nil
+}
+
+func (u *testHookedUser) AfterCreate() error {
+	u.calls = append(u.calls, "AfterCreate")
+	return nil
+}
+
+func (u *testHookedUser) BeforeUpdate() error {
+	u.calls = append(u.calls, "BeforeUpdate")
+	return nil
+}
+
+func (u *testHookedUser) AfterUpdate() error {
+	u.calls = append(u.calls, "AfterUpdate")
+	return nil
+}
+
+// testReleased counts its AfterDelete calls, the hook fails for every vertex
+type testReleased struct {
+	gsmtypes.Vertex
+	Name string `json:"name" gremlin:"name"`
+}
+
+var releasedCalls atomic.Int32
+
+func (r *testReleased) AfterDelete() error {
+	releasedCalls.Add(1)
+	return fmt.Errorf("%w: %s", errHookRejected, r.Name)
+}
+
+func TestHooksValidation(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestBeforeHookAbortsCreate", func(t *testing.T) {
+			t.Parallel()
+			hooked := testHooked{}
+			if err := Create(db, &hooked); !errors.Is(err, errHookRejected) {
+				t.Errorf("Expected hook error, got %v", err)
+			}
+			if len(hooked.calls) != 1 {
+				t.Errorf("Expected only BeforeSave to be called, got %v", hooked.calls)
+			}
+		},
+	)
+	t.Run(
+		"TestBeforeHookAbortsUpdate", func(t *testing.T) {
+			t.Parallel()
+			hooked := testHooked{Vertex: gsmtypes.Vertex{ID: 1}}
+			if err := Update(db, &hooked); !errors.Is(err, errHookRejected) {
+				t.Errorf("Expected hook error, got %v", err)
+			}
+		},
+	)
+	t.Run(
+		"TestBeforeHooksRunBeforeWriting", func(t *testing.T) {
+			t.Parallel()
+			cancelled, cancel := context.WithCancel(context.Background())
+			cancel()
+			hooked := testHooked{Name: "first"}
+			if err := Create(db.WithContext(cancelled), &hooked); !errors.Is(err, ErrQueryAborted) {
+				t.Errorf("Expected ErrQueryAborted, got %v", err)
+			}
+			if hooked.Status != "new" {
+				t.Errorf("Expected BeforeCreate to set the default status, got %s", hooked.Status)
+			}
+			if len(hooked.calls) != 2 || hooked.calls[0] != "BeforeSave" || hooked.calls[1] != "BeforeCreate" {
+				t.Errorf("Expected BeforeSave and BeforeCreate only, got %v", hooked.calls)
+			}
+		},
+	)
+	t.Run(
+		"TestBatchBeforeHook", func(t *testing.T) {
+			t.Parallel()
+			err := CreateMany(db, []*testHooked{{}})
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) || !errors.Is(batchErr.Errs[0], errHookRejected) {
+				t.Errorf("Expected hook error in BatchError, got %v", err)
+			}
+		},
+	)
+	t.Run(
+		"TestHasDeleteHooks", func(t *testing.T) {
+			t.Parallel()
+			if !hasDeleteHooks[testHooked]() {
+				t.Error("Expected delete hooks")
+			}
+			if hasDeleteHooks[testVertex]() {
+				t.Error("Expected no delete hooks")
+			}
+		},
+	)
+}
+
+func TestHooks(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+	t.Run(
+		"TestCreateAndFindHooks", func(t *testing.T) {
+			hooked := testHooked{Name: "first"}
+			if err = Create(db, &hooked); err != nil {
+				t.Fatal(err)
+			}
+			expected := []string{"BeforeSave", "BeforeCreate", "AfterCreate", "AfterSave"}
+			if len(hooked.calls) != len(expected) {
+				t.Errorf("Expected %v, got %v", expected, hooked.calls)
+			}
+			found, findErr := Model[testHooked](db).ID(hooked.ID)
+			if findErr != nil {
+				t.Fatal(findErr)
+			}
+			if found.Status != "new" || len(found.calls) != 1 || found.calls[0] != "AfterFind" {
+				t.Errorf("Expected stored default status and AfterFind, got %+v", found)
+			}
+		},
+	)
+	t.Run(
+		"TestCreateHooksOnlyWhenAdded", func(t *testing.T) {
+			first := testHookedUser{Email: "hooks@example.com"}
+			if err = Create(db, &first); err != nil {
+				t.Fatal(err)
+			}
+			if expected := []string{"AfterCreate"}; !slices.Equal(first.calls, expected) {
+				t.Errorf("Expected %v for the added vertex, got %v", expected, first.calls)
+			}
+			matched := testHookedUser{Email: "hooks@example.com"}
+			if err = Create(db, &matched); err != nil {
+				t.Fatal(err)
+			}
+			if expected := []string{"AfterUpdate"}; !slices.Equal(matched.calls, expected) {
+				t.Errorf("Expected %v for the matched vertex, got %v", expected, matched.calls)
+			}
+			first.calls = nil
+			if err = Create(db, &first); err != nil {
+				t.Fatal(err)
+			}
+			if expected := []string{"BeforeUpdate", "AfterUpdate"}; !slices.Equal(first.calls, expected) {
+				t.Errorf("Expected %v for a value with an id, got %v", expected, first.calls)
+			}
+		},
+	)
+	t.Run(
+		"TestBeforeDeleteAbortsDelete", func(t *testing.T) {
+			for _, status := range []string{"locked", "open"} {
+				if err = Create(db, &testHooked{Name: "delete", Status: status}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			query := Model[testHooked](db).Where("name", comparator.EQ, "delete")
+			if err = query.Delete(); !errors.Is(err, errHookRejected) {
+				t.Errorf("Expected hook error, got %v", err)
+			}
+			count, countErr := Model[testHooked](db).Where("name", comparator.EQ, "delete").Count()
+			if countErr != nil {
+				t.Fatal(countErr)
+			}
+			if count != 2 {
+				t.Errorf("Expected nothing to be deleted, got %d left", count)
+			}
+			if err = Model[testHooked](db).Where("status", comparator.EQ, "open").Delete(); err != nil {
+				t.Error(err)
+			}
+		},
+	)
+	t.Run(
+		"TestEveryAfterDeleteIsCalled", func(t *testing.T) {
+			for _, name := range []string{"first", "second"} {
+				if err = Create(db, &testReleased{Name: name}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = Model[testReleased](db).Delete()
+			if !errors.Is(err, errHookRejected) || !strings.Contains(err.Error(), "first") ||
+				!strings.Contains(err.Error(), "second") {
+				t.Errorf("Expected the errors of both hooks, got %v", err)
+			}
+			if calls := releasedCalls.Load(); calls != 2 {
+				t.Errorf("Expected AfterDelete to be called twice, got %d", calls)
+			}
+		},
+	)
+}
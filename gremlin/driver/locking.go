@@ -0,0 +1,80 @@
+package driver
+
+import (
+	"fmt"
+	"reflect"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// versionGuard is the optimistic locking check of an Update
+// the update only applies when the stored property still equals expected, nothing is checked when property is empty
+type versionGuard struct {
+	property string
+	expected any
+	// field and next are only set for a version field, next is written to the server and into field
+	field reflect.Value
+	next  reflect.Value
+}
+
+// newVersionGuard checks the field tagged version of rv when there is one
+// otherwise last_modified when optimistic locking is enabled on the driver
+func newVersionGuard(db *GremlinDriver, rv reflect.Value) (*versionGuard, error) {
+	if field, tag, ok := versionField(rv); ok {
+		if !field.CanInt() {
+			return nil, fmt.Errorf("version field %s must be an integer, got %s", tag.name, field.Type())
+		}
+		next := reflect.New(field.Type()).Elem()
+		next.SetInt(field.Int() + 1)
+		return &versionGuard{property: tag.name, expected: field.Interface(), field: field, next: next}, nil
+	}
+	if db.optimisticLocking {
+		return &versionGuard{
+			property: gsmtypes.LastModified,
+			expected: rv.FieldByName("LastModified").Interface(),
+		}, nil
+	}
+	return &versionGuard{}, nil
+}
+
+// apply adds the check to query and the incremented version to properties
+func (v *versionGuard) apply(query *gremlingo.GraphTraversal, properties map[string]any) *gremlingo.GraphTraversal {
+	if v.property == "" {
+		return query
+	}
+	if v.next.IsValid() {
+		properties[v.property] = v.next.Interface()
+	}
+	return query.Has(v.property, v.expected)
+}
+
+// commit writes the incremented version into the value once the update applied
+func (v *versionGuard) commit() {
+	if v.next.IsValid() {
+		v.field.Set(v.next)
+	}
+}
+
+// notApplied returns the error of an update which matched no vertex
+func (v *versionGuard) notApplied(label string, id any) error {
+	if v.property == "" {
+		return fmt.Errorf("%s vertex %v not found", label, id)
+	}
+	return fmt.Errorf("%w: %s vertex %v was changed or deleted since it was loaded", ErrStaleObject, label, id)
+}
+
+// versionIncrement returns the property of the version field of rt and an anonymous traversal adding one to its
+// stored value, it is used by updates which write many vertices without loading them, the property is empty when
+// rt has no version field
+func versionIncrement(rt reflect.Type) (string, *gremlingo.GraphTraversal, error) {
+	field, tag, ok := versionField(reflect.New(rt).Elem())
+	if !ok {
+		return "", nil, nil
+	}
+	if !field.CanInt() {
+		return "", nil, fmt.Errorf("version field %s must be an integer, got %s", tag.name, field.Type())
+	}
+	increment := anonymousTraversal.Union(anonymousTraversal.Values(tag.name), anonymousTraversal.Constant(1)).Sum()
+	return tag.name, increment, nil
+}
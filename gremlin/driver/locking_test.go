@@ -0,0 +1,223 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testDocument struct {
+	gsmtypes.Vertex
+	Title   string `json:"title"   gremlin:"title"`
+	Version int    `json:"version" gremlin:"version,version"`
+}
+
+type testInvalidVersion struct {
+	gsmtypes.Vertex
+	Version string `json:"version" gremlin:"version,version"`
+}
+
+func TestLockingValidation(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestVersionField", func(t *testing.T) {
+			t.Parallel()
+			document := testDocument{Vertex: gsmtypes.Vertex{ID: 1}, Version: 3}
+			guard, err := newVersionGuard(db, reflect.ValueOf(&document).Elem())
+			if err != nil {
+				t.Fatal(err)
+			}
+			properties := map[string]any{"title": "a"}
+			got := translate(t, guard.apply(db.g.V(1), properties))
+			if got != "g.V(1).has('version',3)" {
+				t.Errorf("Expected version check, got %s", got)
+			}
+			if properties["version"] != 4 {
+				t.Errorf("Expected incremented version to be written, got %v", properties)
+			}
+			guard.commit()
+			if document.Version != 4 {
+				t.Errorf("Expected version 4 after commit, got %d", document.Version)
+			}
+		},
+	)
+	t.Run(
+		"TestLastModified", func(t *testing.T) {
+			t.Parallel()
+			locking := newOfflineDriver()
+			locking.optimisticLocking = true
+			loaded := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+			vertex := testVertex{Vertex: gsmtypes.Vertex{ID: 1, LastModified: loaded}}
+			guard, err := newVersionGuard(locking, reflect.ValueOf(&vertex).Elem())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if guard.property != gsmtypes.LastModified || guard.expected != loaded {
+				t.Errorf("Expected last_modified check, got %+v", guard)
+			}
+
+			guard, err = newVersionGuard(db, reflect.ValueOf(&vertex).Elem())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if guard.property != "" {
+				t.Errorf("Expected no check without optimistic locking, got %+v", guard)
+			}
+			if err = guard.notApplied("test_vertex", 1); errors.Is(err, ErrStaleObject) {
+				t.Errorf("Expected not found error, got %v", err)
+			}
+		},
+	)
+	t.Run(
+		"TestBatchVersionCheck", func(t *testing.T) {
+			t.Parallel()
+			document := testDocument{Vertex: gsmtypes.Vertex{ID: 1}, Title: "a", Version: 3}
+			item, err := newBatchItem(db, 0, &document, true, nil, time.Now().UTC())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "g.coalesce(V(1).hasLabel('test_document').has('version',3).constant('found')," +
+				"V(1).hasLabel('test_document').constant('stale'),constant('missing'))"
+			if got := translate(t, item.merge.storedState()); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+			query, _ := batchTraversal(db, []*batchItem{item})
+			got := translate(t, query)
+			if !strings.HasPrefix(got, "g.V(1).hasLabel('test_document').has('version',3).property(") ||
+				!strings.Contains(got, "property(single,'version',4)") {
+				t.Errorf("Expected the version to be checked and incremented, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestVersionIncrement", func(t *testing.T) {
+			t.Parallel()
+			name, increment, err := versionIncrement(reflect.TypeFor[testDocument]())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := translate(t, increment); name != "version" ||
+				got != "g.union(values('version'),constant(1)).sum()" {
+				t.Errorf("Expected the stored version to be incremented, got %s %s", name, got)
+			}
+			if name, _, err = versionIncrement(reflect.TypeFor[testVertex]()); name != "" || err != nil {
+				t.Errorf("Expected no increment without a version field, got %s %v", name, err)
+			}
+		},
+	)
+	t.Run(
+		"TestInvalidVersionField", func(t *testing.T) {
+			t.Parallel()
+			value := testInvalidVersion{Vertex: gsmtypes.Vertex{ID: 1}}
+			if err := Update(db, &value); err == nil {
+				t.Error("Expected error for a version field which is not an integer")
+			}
+		},
+	)
+}
+
+func TestLocking(t *testing.T) {
+	db, err := Open(DbURL, Gremlin, WithOptimisticLocking())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+	t.Run(
+		"TestVersionConflict", func(t *testing.T) {
+			document := testDocument{Title: "draft", Version: 1}
+			if err = Create(db, &document); err != nil {
+				t.Fatal(err)
+			}
+			first, _ := Model[testDocument](db).ID(document.ID)
+			second, _ := Model[testDocument](db).ID(document.ID)
+			first.Title = "first"
+			if err = Update(db, &first); err != nil {
+				t.Fatal(err)
+			}
+			if first.Version != 2 {
+				t.Errorf("Expected version 2, got %d", first.Version)
+			}
+			second.Title = "second"
+			if err = Save(db, &second); !errors.Is(err, ErrStaleObject) {
+				t.Errorf("Expected ErrStaleObject, got %v", err)
+			}
+			stored, _ := Model[testDocument](db).ID(document.ID)
+			if stored.Title != "first" {
+				t.Errorf("Expected first write to be kept, got %s", stored.Title)
+			}
+		},
+	)
+	t.Run(
+		"TestLastModifiedConflict", func(t *testing.T) {
+			vertex := testVertex{Name: "first"}
+			if err = Create(db, &vertex); err != nil {
+				t.Fatal(err)
+			}
+			stale := vertex
+			vertex.Name = "second"
+			if err = Update(db, &vertex, "name"); err != nil {
+				t.Fatal(err)
+			}
+			stale.Name = "third"
+			if err = Update(db, &stale, "name"); !errors.Is(err, ErrStaleObject) {
+				t.Errorf("Expected ErrStaleObject, got %v", err)
+			}
+		},
+	)
+	t.Run(
+		"TestSaveManyVersionConflict", func(t *testing.T) {
+			document := testDocument{Title: "draft", Version: 1}
+			if err = Create(db, &document); err != nil {
+				t.Fatal(err)
+			}
+			stale := document
+			document.Title = "first"
+			if err = SaveMany(db, []*testDocument{&document}); err != nil {
+				t.Fatal(err)
+			}
+			if document.Version != 2 {
+				t.Errorf("Expected version 2, got %d", document.Version)
+			}
+			stale.Title = "second"
+			var batchErr *BatchError
+			if err = SaveMany(db, []*testDocument{&stale}); !errors.As(err, &batchErr) ||
+				!errors.Is(batchErr.Errs[0], ErrStaleObject) {
+				t.Errorf("Expected ErrStaleObject for the stale value, got %v", err)
+			}
+			if err = Create(db, &stale); !errors.Is(err, ErrStaleObject) {
+				t.Errorf("Expected ErrStaleObject from Create with a stale id, got %v", err)
+			}
+			stored, _ := Model[testDocument](db).ID(document.ID)
+			if stored.Title != "first" || stored.Version != 2 {
+				t.Errorf("Expected the first write to be kept, got %+v", stored)
+			}
+		},
+	)
+	t.Run(
+		"TestQueryUpdateIncrementsVersion", func(t *testing.T) {
+			document := testDocument{Title: "query", Version: 1}
+			if err = Create(db, &document); err != nil {
+				t.Fatal(err)
+			}
+			err = Model[testDocument](db).Where("title", comparator.EQ, "query").Update("title", "updated")
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored, _ := Model[testDocument](db).ID(document.ID)
+			if stored.Title != "updated" || stored.Version != 2 {
+				t.Errorf("Expected the version to be incremented, got %+v", stored)
+			}
+			document.Title = "stale"
+			if err = Update(db, &document); !errors.Is(err, ErrStaleObject) {
+				t.Errorf("Expected ErrStaleObject after a query update, got %v", err)
+			}
+		},
+	)
+}
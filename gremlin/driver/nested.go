@@ -0,0 +1,165 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// nestedFieldSeparator joins the name of a struct or map field with the names of its nested properties
+// e.g. the field Address `gremlin:"address"` with the nested field City `gremlin:"city"` is stored as address.city
+const nestedFieldSeparator = "."
+
+// mapKeyWildcard stands for any key of a map field in the set returned by gremlinFieldNames
+const mapKeyWildcard = "*"
+
+// isNestedStruct reports whether fields of type rt are flattened into prefixed properties
+// structs which gremlin-go serializes on its own, such as time.Time, or which convert themselves are not
+func isNestedStruct(rt reflect.Type) bool {
+	if rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct || rt == reflect.TypeFor[time.Time]() {
+		return false
+	}
+	return !convertsItself(rt)
+}
+
+// isStringMap reports whether fields of type rt are flattened into a property per key
+func isStringMap(rt reflect.Type) bool {
+	return rt.Kind() == reflect.Map && rt.Key().Kind() == reflect.String
+}
+
+// addFieldProperty adds the property or, for nested structs and maps, the prefixed properties of a tagged field
+// zero required fields of nested structs are handled like in addStructProperties
+func addFieldProperty(
+	name string,
+	tag gremlinTag,
+	fieldValue reflect.Value,
+	properties map[string]any,
+	missing *[]string,
+) error {
+	switch {
+	case tag.json:
+		encoded, err := json.Marshal(fieldValue.Interface())
+		if err != nil {
+			return fmt.Errorf("error encoding property %s: %w", name, err)
+		}
+		properties[name] = string(encoded)
+	case isNestedStruct(fieldValue.Type()):
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				return nil
+			}
+			fieldValue = fieldValue.Elem()
+		}
+		return addStructProperties(fieldValue, name+nestedFieldSeparator, properties, missing)
+	case isStringMap(fieldValue.Type()):
+		iter := fieldValue.MapRange()
+		for iter.Next() {
+			key := name + nestedFieldSeparator + iter.Key().String()
+			if err := setPropertyValue(properties, key, iter.Value()); err != nil {
+				return fmt.Errorf("error encoding property %s: %w", key, err)
+			}
+		}
+	default:
+		if err := setPropertyValue(properties, name, fieldValue); err != nil {
+			return fmt.Errorf("error encoding property %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// flattenedFields returns the tags of the top level fields of rt stored as prefixed properties
+// readonly fields are left out as they are never written
+func flattenedFields(rt reflect.Type) []gremlinTag {
+	flattened := make([]gremlinTag, 0)
+	for _, property := range schemaOf(rt).properties {
+		if (property.encoding == encodeNested || property.encoding == encodeMap) && !property.tag.readOnly {
+			flattened = append(flattened, property.tag)
+		}
+	}
+	return flattened
+}
+
+// prefixedProperties returns the properties starting with prefix keeping their full name
+func prefixedProperties(properties map[string]any, prefix string) map[string]any {
+	selected := make(map[string]any)
+	for key, value := range properties {
+		if strings.HasPrefix(key, prefix) {
+			selected[key] = value
+		}
+	}
+	return selected
+}
+
+// prefixedValues returns the values of the properties starting with prefix keyed by the rest of their name
+func prefixedValues(stringMap map[string]any, prefix string) map[string]any {
+	values := make(map[string]any)
+	for key, value := range stringMap {
+		if rest, ok := strings.CutPrefix(key, prefix); ok {
+			values[rest] = value
+		}
+	}
+	return values
+}
+
+// unloadNestedField unloads a JSON encoded, nested struct or map field from the properties of its vertex
+// handled is false when the field is a plain property
+func unloadNestedField(field reflect.Value, tag gremlinTag, stringMap map[string]any) (bool, error) {
+	switch {
+	case tag.json:
+		encoded, ok := stringMap[tag.name].(string)
+		if !ok {
+			return true, nil
+		}
+		if err := json.Unmarshal([]byte(encoded), field.Addr().Interface()); err != nil {
+			return true, fmt.Errorf("error decoding property %s: %w", tag.name, err)
+		}
+		return true, nil
+	case isNestedStruct(field.Type()):
+		values := prefixedValues(stringMap, tag.name+nestedFieldSeparator)
+		if len(values) == 0 {
+			return true, nil
+		}
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		return true, recursivelyUnloadIntoStruct(field.Addr().Interface(), values)
+	case isStringMap(field.Type()):
+		values := prefixedValues(stringMap, tag.name+nestedFieldSeparator)
+		if len(values) == 0 {
+			return true, nil
+		}
+		unloaded := reflect.MakeMapWithSize(field.Type(), len(values))
+		for key, value := range values {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := assignValue(elem, value); err != nil {
+				return true, fmt.Errorf("error unloading property %s%s%s: %w", tag.name, nestedFieldSeparator, key, err)
+			}
+			unloaded.SetMapIndex(reflect.ValueOf(key).Convert(field.Type().Key()), elem)
+		}
+		field.Set(unloaded)
+		return true, nil
+	}
+	return false, nil
+}
+
+// hasGremlinField reports whether name is in the set returned by gremlinFieldNames
+// keys of map fields are accepted through the map's wildcard entry
+func hasGremlinField(fields map[string]struct{}, name string) bool {
+	if _, ok := fields[name]; ok {
+		return true
+	}
+	index := strings.LastIndex(name, nestedFieldSeparator)
+	if index < 0 {
+		return false
+	}
+	_, ok := fields[name[:index+1]+mapKeyWildcard]
+	return ok
+}
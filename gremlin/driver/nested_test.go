@@ -0,0 +1,203 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testAddress struct {
+	City    string `json:"city"    gremlin:"city"`
+	Country string `json:"country" gremlin:"country"`
+}
+
+type testCustomer struct {
+	gsmtypes.Vertex
+	Name     string            `json:"name"     gremlin:"name"`
+	Address  testAddress       `json:"address"  gremlin:"address"`
+	Billing  *testAddress      `json:"billing"  gremlin:"billing"`
+	Labels   map[string]string `json:"labels"   gremlin:"labels"`
+	Settings map[string]int    `json:"settings" gremlin:"settings,json"`
+}
+
+func TestNestedOffline(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	customer := testCustomer{
+		Name:     "Ada",
+		Address:  testAddress{City: "London", Country: "UK"},
+		Labels:   map[string]string{"team": "core", "tier": "gold"},
+		Settings: map[string]int{"limit": 5},
+	}
+	t.Run(
+		"TestFlattenedProperties", func(t *testing.T) {
+			t.Parallel()
+			_, properties, err := structToMap(&customer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := map[string]any{
+				"address.city":    "London",
+				"address.country": "UK",
+				"labels.team":     "core",
+				"labels.tier":     "gold",
+				"settings":        `{"limit":5}`,
+			}
+			for key, value := range expected {
+				if properties[key] != value {
+					t.Errorf("Expected %s to be %v, got %v", key, value, properties[key])
+				}
+			}
+			for _, key := range []string{"address", "labels", "billing", "billing.city"} {
+				if _, ok := properties[key]; ok {
+					t.Errorf("Expected no %s property, got %v", key, properties[key])
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestUnloadNestedProperties", func(t *testing.T) {
+			t.Parallel()
+			_, properties, err := structToMap(&customer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			properties["billing.city"] = "Paris"
+			result := make(map[any]any, len(properties))
+			for key, value := range properties {
+				result[key] = value
+			}
+			var unloaded testCustomer
+			if err = recursivelyUnloadIntoStruct(&unloaded, mustStringMap(t, result)); err != nil {
+				t.Fatal(err)
+			}
+			expected := customer
+			expected.Billing = &testAddress{City: "Paris"}
+			if !reflect.DeepEqual(unloaded, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, unloaded)
+			}
+		},
+	)
+	t.Run(
+		"TestWhereNestedField", func(t *testing.T) {
+			t.Parallel()
+			query := Model[testCustomer](db).
+				Where("address.city", comparator.EQ, "London").
+				Where("labels.team", comparator.EQ, "core")
+			expected := "g.V().hasLabel('test_customer').has('address.city','London').has('labels.team','core')"
+			if got := translate(t, query.BuildQuery()); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+			var validationErr *ValidationError
+			_, err := Model[testCustomer](db).Where("address.zip", comparator.EQ, "1").Find()
+			if !errors.As(err, &validationErr) {
+				t.Errorf("Expected ValidationError for an unknown nested field, got %v", err)
+			}
+		},
+	)
+	t.Run(
+		"TestSaveWithIDClearsRemovedKeys", func(t *testing.T) {
+			t.Parallel()
+			saved := customer
+			saved.ID = 1
+			saved.Labels = map[string]string{"team": "core"}
+			merge, err := newValueMerge(db, &saved, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			item, err := newBatchItem(db, 0, &saved, true, nil, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, query := range map[string]*gremlingo.GraphTraversal{
+				"Create":   merge.traversal(db, nil),
+				"SaveMany": item.merge.traversal(db, nil),
+			} {
+				got := translate(t, query)
+				if !strings.HasPrefix(got, "g.V(1).hasLabel('test_customer')") ||
+					!strings.Contains(got, "sideEffect(properties().hasKey(startingWith('labels.')).drop())") ||
+					!strings.Contains(got, "property(single,'labels.team','core')") ||
+					strings.Contains(got, "tier") {
+					t.Errorf("%s: expected the stored labels to be replaced, got %s", name, got)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestUpdateNestedFields", func(t *testing.T) {
+			t.Parallel()
+			_, properties, err := structToMap(&customer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rt := reflect.TypeFor[testCustomer]()
+			selected, cleared, err := selectUpdateFields(rt, properties, []string{"labels", "address.city"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(selected) != 3 || selected["labels.team"] != "core" || selected["address.city"] != "London" {
+				t.Errorf("Expected the labels and the city, got %v", selected)
+			}
+			if !reflect.DeepEqual(cleared, []string{"labels."}) {
+				t.Errorf("Expected the stored labels to be cleared, got %v", cleared)
+			}
+			if _, cleared, _ = selectUpdateFields(rt, properties, nil); len(cleared) != 3 {
+				t.Errorf("Expected address, billing and labels to be cleared, got %v", cleared)
+			}
+		},
+	)
+}
+
+func mustStringMap(t *testing.T, result map[any]any) map[string]any {
+	t.Helper()
+	stringMap, err := toStringMap(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stringMap
+}
+
+func TestNested(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+
+	customer := testCustomer{
+		Name:     "Ada",
+		Address:  testAddress{City: "London", Country: "UK"},
+		Labels:   map[string]string{"team": "core", "tier": "gold"},
+		Settings: map[string]int{"limit": 5},
+	}
+	if err = Create(db, &customer); err != nil {
+		t.Fatal(err)
+	}
+	found, err := Model[testCustomer](db).Where("address.city", comparator.EQ, "London").Take()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(found.Address, customer.Address) || !reflect.DeepEqual(found.Labels, customer.Labels) ||
+		found.Settings["limit"] != 5 || found.Billing != nil {
+		t.Errorf("Expected %+v, got %+v", customer, found)
+	}
+
+	delete(found.Labels, "tier")
+	if err = Update(db, &found, "labels"); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := Model[testCustomer](db).ID(found.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(updated.Labels, map[string]string{"team": "core"}) {
+		t.Errorf("Expected the removed label to be dropped, got %v", updated.Labels)
+	}
+}
@@ -0,0 +1,291 @@
+package driver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// Options configures the connection opened by Open
+// zero values leave the gremlin-go default in place, the zero Path, DatabaseDriver, TraversalSource and BatchSize
+// of an Options literal passed to Open are replaced by their defaults, an Options value returned by DefaultOptions
+// or LoadOptionsFromEnv is applied as it is so an empty Path set on it is kept
+type Options struct {
+	// Path is appended to the url passed to Open, defaults to /gremlin
+	Path string
+	// DatabaseDriver selects the database specific behaviour, defaults to Gremlin
+	DatabaseDriver DatabaseDriver
+	// TraversalSource is the name of the traversal source on the server, defaults to g
+	TraversalSource string
+	Username        string
+	Password        string
+	TLSConfig       *tls.Config
+	// PoolSize is the maximum number of concurrent connections
+	PoolSize          int
+	KeepAliveInterval time.Duration
+	ConnectionTimeout time.Duration
+	WriteDeadline     time.Duration
+	EnableCompression bool
+	// BatchSize is the number of vertices CreateMany and SaveMany write per traversal, defaults to 100
+	BatchSize int
+	// OptimisticLocking makes Update and Save fail with ErrStaleObject when the stored last_modified
+	// differs from the value, types with a field tagged version are always checked on that field
+	OptimisticLocking bool
+	// Registry limits queries to the registered models and provides their labels, nil allows every model
+	Registry *Registry
+	// defaulted is set by DefaultOptions, the zero fields of an Options value without it are given their defaults
+	defaulted bool
+}
+
+// Option configures Open, a DatabaseDriver is an Option so Open(url, driver.Neptune) keeps working
+type Option interface {
+	apply(options *Options)
+}
+
+type optionFunc func(options *Options)
+
+func (f optionFunc) apply(options *Options) {
+	f(options)
+}
+
+func (d DatabaseDriver) apply(options *Options) {
+	options.DatabaseDriver = d
+}
+
+// apply replaces every option set so far, Open only accepts an Options value as its first option
+// so an Options loaded with LoadOptionsFromEnv is overridden by the options after it
+// the zero fields which have a default are given it unless the value was built from DefaultOptions
+func (o Options) apply(options *Options) {
+	if !o.defaulted {
+		defaults := DefaultOptions()
+		if o.Path == "" {
+			o.Path = defaults.Path
+		}
+		if o.DatabaseDriver == "" {
+			o.DatabaseDriver = defaults.DatabaseDriver
+		}
+		if o.TraversalSource == "" {
+			o.TraversalSource = defaults.TraversalSource
+		}
+		if o.BatchSize <= 0 {
+			o.BatchSize = defaults.BatchSize
+		}
+		o.defaulted = true
+	}
+	*options = o
+}
+
+// validateOptions rejects an Options value passed after another option, it would silently discard that option
+// and nil options, they would panic once applied
+func validateOptions(opts []Option) error {
+	for i, opt := range opts {
+		if opt == nil || opt == Option((*Options)(nil)) {
+			return fmt.Errorf("option %d of Open is nil", i+1)
+		}
+		switch opt.(type) {
+		case Options, *Options:
+			if i > 0 {
+				return fmt.Errorf(
+					"option %d of Open is an Options value which replaces every option before it, pass it first", i+1,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// DefaultOptions returns the options Open starts from
+func DefaultOptions() Options {
+	return Options{
+		Path:            "/gremlin",
+		DatabaseDriver:  Gremlin,
+		TraversalSource: "g",
+		BatchSize:       defaultBatchSize,
+		defaulted:       true,
+	}
+}
+
+// WithDatabaseDriver sets the database specific behaviour
+func WithDatabaseDriver(dbDriver DatabaseDriver) Option {
+	return dbDriver
+}
+
+// WithBasicAuth authenticates with the username and password
+func WithBasicAuth(username string, password string) Option {
+	return optionFunc(func(options *Options) {
+		options.Username = username
+		options.Password = password
+	})
+}
+
+// WithTLS sets the tls config used for wss urls
+func WithTLS(config *tls.Config) Option {
+	return optionFunc(func(options *Options) {
+		options.TLSConfig = config
+	})
+}
+
+// WithPoolSize sets the maximum number of concurrent connections
+func WithPoolSize(size int) Option {
+	return optionFunc(func(options *Options) {
+		options.PoolSize = size
+	})
+}
+
+// WithTraversalSource sets the name of the traversal source on the server
+func WithTraversalSource(name string) Option {
+	return optionFunc(func(options *Options) {
+		options.TraversalSource = name
+	})
+}
+
+// WithPath sets the path appended to the url, pass an empty path when the url already contains it
+func WithPath(path string) Option {
+	return optionFunc(func(options *Options) {
+		options.Path = path
+	})
+}
+
+// WithKeepAlive sets the interval of the keep alive pings
+func WithKeepAlive(interval time.Duration) Option {
+	return optionFunc(func(options *Options) {
+		options.KeepAliveInterval = interval
+	})
+}
+
+// WithTimeouts sets the timeout for opening a connection and for writing a request
+func WithTimeouts(connectionTimeout time.Duration, writeDeadline time.Duration) Option {
+	return optionFunc(func(options *Options) {
+		options.ConnectionTimeout = connectionTimeout
+		options.WriteDeadline = writeDeadline
+	})
+}
+
+// WithCompression enables websocket compression
+func WithCompression() Option {
+	return optionFunc(func(options *Options) {
+		options.EnableCompression = true
+	})
+}
+
+// WithBatchSize sets the number of vertices CreateMany and SaveMany write per traversal
+func WithBatchSize(size int) Option {
+	return optionFunc(func(options *Options) {
+		options.BatchSize = size
+	})
+}
+
+// WithOptimisticLocking makes Update and Save check the stored last_modified, see ErrStaleObject
+func WithOptimisticLocking() Option {
+	return optionFunc(func(options *Options) {
+		options.OptimisticLocking = true
+	})
+}
+
+// LoadOptionsFromEnv returns the default options overridden by the GSM_* environment variables which are set
+//
+//	GSM_PATH, GSM_DATABASE_DRIVER, GSM_TRAVERSAL_SOURCE, GSM_USERNAME, GSM_PASSWORD,
+//	GSM_POOL_SIZE, GSM_KEEP_ALIVE, GSM_CONNECTION_TIMEOUT, GSM_WRITE_DEADLINE, GSM_COMPRESSION, GSM_BATCH_SIZE,
+//	GSM_OPTIMISTIC_LOCKING
+//
+// durations use the time.ParseDuration format, e.g. 30s
+func LoadOptionsFromEnv() (Options, error) {
+	options := DefaultOptions()
+	if path, ok := os.LookupEnv("GSM_PATH"); ok {
+		options.Path = path
+	}
+	if dbDriver := os.Getenv("GSM_DATABASE_DRIVER"); dbDriver != "" {
+		options.DatabaseDriver = DatabaseDriver(dbDriver)
+	}
+	if source := os.Getenv("GSM_TRAVERSAL_SOURCE"); source != "" {
+		options.TraversalSource = source
+	}
+	options.Username = os.Getenv("GSM_USERNAME")
+	options.Password = os.Getenv("GSM_PASSWORD")
+
+	var err error
+	if options.PoolSize, err = envInt("GSM_POOL_SIZE"); err != nil {
+		return options, err
+	}
+	if options.KeepAliveInterval, err = envDuration("GSM_KEEP_ALIVE"); err != nil {
+		return options, err
+	}
+	if options.ConnectionTimeout, err = envDuration("GSM_CONNECTION_TIMEOUT"); err != nil {
+		return options, err
+	}
+	if options.WriteDeadline, err = envDuration("GSM_WRITE_DEADLINE"); err != nil {
+		return options, err
+	}
+	batchSize, err := envInt("GSM_BATCH_SIZE")
+	if err != nil {
+		return options, err
+	}
+	if batchSize > 0 {
+		options.BatchSize = batchSize
+	}
+	if compression := os.Getenv("GSM_COMPRESSION"); compression != "" {
+		if options.EnableCompression, err = strconv.ParseBool(compression); err != nil {
+			return options, fmt.Errorf("invalid GSM_COMPRESSION: %w", err)
+		}
+	}
+	if locking := os.Getenv("GSM_OPTIMISTIC_LOCKING"); locking != "" {
+		if options.OptimisticLocking, err = strconv.ParseBool(locking); err != nil {
+			return options, fmt.Errorf("invalid GSM_OPTIMISTIC_LOCKING: %w", err)
+		}
+	}
+	return options, nil
+}
+
+func envInt(key string) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return parsed, nil
+}
+
+func envDuration(key string) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return parsed, nil
+}
+
+// configure maps the options onto the gremlin-go connection settings
+func (o Options) configure(settings *gremlingo.DriverRemoteConnectionSettings) {
+	if o.TraversalSource != "" {
+		settings.TraversalSource = o.TraversalSource
+	}
+	if o.Username != "" || o.Password != "" {
+		settings.AuthInfo = gremlingo.BasicAuthInfo(o.Username, o.Password)
+	}
+	if o.TLSConfig != nil {
+		settings.TlsConfig = o.TLSConfig
+	}
+	if o.PoolSize > 0 {
+		settings.MaximumConcurrentConnections = o.PoolSize
+	}
+	if o.KeepAliveInterval > 0 {
+		settings.KeepAliveInterval = o.KeepAliveInterval
+	}
+	if o.ConnectionTimeout > 0 {
+		settings.ConnectionTimeout = o.ConnectionTimeout
+	}
+	if o.WriteDeadline > 0 {
+		settings.WriteDeadline = o.WriteDeadline
+	}
+	settings.EnableCompression = o.EnableCompression
+}
@@ -0,0 +1,176 @@
+package driver
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+func TestOptionsOffline(t *testing.T) {
+	t.Parallel()
+	t.Run(
+		"TestDefaults", func(t *testing.T) {
+			t.Parallel()
+			options := DefaultOptions()
+			if options.Path != "/gremlin" || options.DatabaseDriver != Gremlin || options.TraversalSource != "g" {
+				t.Errorf("Unexpected default options %+v", options)
+			}
+		},
+	)
+	t.Run(
+		"TestOptionsValueMustBeFirst", func(t *testing.T) {
+			t.Parallel()
+			if err := validateOptions([]Option{DefaultOptions(), Neptune}); err != nil {
+				t.Errorf("Expected an Options value first to be accepted, got %v", err)
+			}
+			options := DefaultOptions()
+			for _, opts := range [][]Option{{Neptune, options}, {WithPoolSize(4), &options}} {
+				if _, err := Open("ws://localhost:8182", opts...); err == nil ||
+					!strings.Contains(err.Error(), "option 2 of Open") {
+					t.Errorf("Expected an Options value after another option to be rejected, got %v", err)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestNilOptions", func(t *testing.T) {
+			t.Parallel()
+			var options *Options
+			for _, opts := range [][]Option{{options}, {Neptune, nil}} {
+				if _, err := Open("ws://localhost:8182", opts...); err == nil || !strings.Contains(err.Error(), "nil") {
+					t.Errorf("Expected a nil option to be rejected, got %v", err)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestPartialOptionsValue", func(t *testing.T) {
+			t.Parallel()
+			options := DefaultOptions()
+			Options{Username: "user", Password: "secret"}.apply(&options)
+			if options.Path != "/gremlin" || options.DatabaseDriver != Gremlin || options.TraversalSource != "g" ||
+				options.BatchSize != defaultBatchSize {
+				t.Errorf("Expected the defaults for the zero fields, got %+v", options)
+			}
+			if options.Username != "user" || options.Password != "secret" {
+				t.Errorf("Expected the set fields to be kept, got %+v", options)
+			}
+			withoutPath := DefaultOptions()
+			withoutPath.Path = ""
+			withoutPath.apply(&options)
+			if options.Path != "" {
+				t.Errorf("Expected the empty path of a value built from DefaultOptions to be kept, got %s", options.Path)
+			}
+		},
+	)
+	t.Run(
+		"TestApply", func(t *testing.T) {
+			t.Parallel()
+			tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+			options := DefaultOptions()
+			for _, opt := range []Option{
+				Neptune,
+				WithBasicAuth("user", "secret"),
+				WithTLS(tlsConfig),
+				WithPoolSize(4),
+				WithTraversalSource("g2"),
+				WithPath(""),
+				WithKeepAlive(time.Minute),
+				WithTimeouts(time.Second, 2*time.Second),
+				WithCompression(),
+			} {
+				opt.apply(&options)
+			}
+			if options.DatabaseDriver != Neptune {
+				t.Errorf("Expected database driver %s, got %s", Neptune, options.DatabaseDriver)
+			}
+			if options.Path != "" {
+				t.Errorf("Expected empty path, got %s", options.Path)
+			}
+
+			settings := gremlingo.DriverRemoteConnectionSettings{}
+			options.configure(&settings)
+			if ok, username, password := settings.AuthInfo.GetBasicAuth(); !ok || username != "user" ||
+				password != "secret" {
+				t.Errorf("Expected basic auth user/secret, got %s/%s", username, password)
+			}
+			if settings.TlsConfig != tlsConfig {
+				t.Error("Expected tls config to be set")
+			}
+			if settings.MaximumConcurrentConnections != 4 {
+				t.Errorf("Expected pool size 4, got %d", settings.MaximumConcurrentConnections)
+			}
+			if settings.TraversalSource != "g2" {
+				t.Errorf("Expected traversal source g2, got %s", settings.TraversalSource)
+			}
+			if settings.KeepAliveInterval != time.Minute || settings.ConnectionTimeout != time.Second ||
+				settings.WriteDeadline != 2*time.Second {
+				t.Errorf("Unexpected durations %+v", settings)
+			}
+			if !settings.EnableCompression {
+				t.Error("Expected compression to be enabled")
+			}
+		},
+	)
+	t.Run(
+		"TestZeroValuesKeepSettings", func(t *testing.T) {
+			t.Parallel()
+			settings := gremlingo.DriverRemoteConnectionSettings{
+				TraversalSource:              "g",
+				MaximumConcurrentConnections: 8,
+				KeepAliveInterval:            time.Second,
+			}
+			Options{}.configure(&settings)
+			if settings.TraversalSource != "g" || settings.MaximumConcurrentConnections != 8 ||
+				settings.KeepAliveInterval != time.Second || settings.AuthInfo != nil {
+				t.Errorf("Expected settings to be left untouched, got %+v", settings)
+			}
+		},
+	)
+}
+
+func TestOptionsEnvOffline(t *testing.T) {
+	t.Setenv("GSM_PATH", "/custom")
+	t.Setenv("GSM_DATABASE_DRIVER", "neptune")
+	t.Setenv("GSM_TRAVERSAL_SOURCE", "g2")
+	t.Setenv("GSM_USERNAME", "user")
+	t.Setenv("GSM_PASSWORD", "secret")
+	t.Setenv("GSM_POOL_SIZE", "4")
+	t.Setenv("GSM_KEEP_ALIVE", "30s")
+	t.Setenv("GSM_COMPRESSION", "true")
+	t.Setenv("GSM_BATCH_SIZE", "50")
+	t.Setenv("GSM_OPTIMISTIC_LOCKING", "true")
+
+	options, err := LoadOptionsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DefaultOptions()
+	expected.Path = "/custom"
+	expected.DatabaseDriver = Neptune
+	expected.TraversalSource = "g2"
+	expected.Username = "user"
+	expected.Password = "secret"
+	expected.PoolSize = 4
+	expected.KeepAliveInterval = 30 * time.Second
+	expected.EnableCompression = true
+	expected.BatchSize = 50
+	expected.OptimisticLocking = true
+	if options != expected {
+		t.Errorf("Expected %+v, got %+v", expected, options)
+	}
+
+	// options passed after the environment options override them
+	WithPoolSize(2).apply(&options)
+	if options.PoolSize != 2 {
+		t.Errorf("Expected pool size 2, got %d", options.PoolSize)
+	}
+
+	t.Setenv("GSM_POOL_SIZE", "many")
+	if _, err = LoadOptionsFromEnv(); err == nil {
+		t.Error("Expected error for invalid GSM_POOL_SIZE")
+	}
+}
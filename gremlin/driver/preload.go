@@ -0,0 +1,187 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// preloadTree holds the nested field names to preload keyed by the go field name
+// e.g. the paths "Cars" and "Cars.Parts" result in {Cars: {Parts: {}}}
+type preloadTree map[string]preloadTree
+
+func newPreloadTree(paths []string) preloadTree {
+	tree := preloadTree{}
+	for _, path := range paths {
+		node := tree
+		for name := range strings.SplitSeq(path, ".") {
+			child, ok := node[name]
+			if !ok {
+				child = preloadTree{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+// cascadeEdgeOption marks the vertices at the end of an out edge as owned, see Query.Delete
+const cascadeEdgeOption = "cascade"
+
+// edgeTag is the parsed value of a gremlinEdge tag such as `gremlinEdge:"owns,out"` or `gremlinEdge:"owns,cascade"`
+type edgeTag struct {
+	label     string
+	direction Direction
+	cascade   bool
+}
+
+func parseEdgeTag(tag string) (edgeTag, error) {
+	label, options, _ := strings.Cut(tag, ",")
+	if label == "" {
+		return edgeTag{}, fmt.Errorf("gremlinEdge tag %q has no edge label", tag)
+	}
+	parsed := edgeTag{label: label, direction: Out}
+	for option := range strings.SplitSeq(options, ",") {
+		switch strings.TrimSpace(option) {
+		case "", "out":
+		case "in":
+			parsed.direction = In
+		case "both":
+			parsed.direction = Both
+		case cascadeEdgeOption:
+			parsed.cascade = true
+		default:
+			return edgeTag{}, fmt.Errorf("gremlinEdge tag %q has unknown option %q", tag, option)
+		}
+	}
+	if parsed.cascade && parsed.direction != Out {
+		return edgeTag{}, fmt.Errorf("gremlinEdge tag %q can only cascade along out edges", tag)
+	}
+	return parsed, nil
+}
+
+// preloadFieldType returns the struct type held by a preloadable field and whether the field holds a single vertex
+// supported field types are []T, []*T and *T where T is a struct
+func preloadFieldType(fieldType reflect.Type) (reflect.Type, bool, error) {
+	var elemType reflect.Type
+	single := false
+	switch fieldType.Kind() { //nolint: exhaustive // only slices and pointers can hold preloaded vertices
+	case reflect.Slice:
+		elemType = fieldType.Elem()
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+	case reflect.Ptr:
+		elemType = fieldType.Elem()
+		single = true
+	default:
+		return nil, false, fmt.Errorf("preload field must be a slice or pointer, got %s", fieldType)
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, false, fmt.Errorf("preload field must hold structs, got %s", elemType)
+	}
+	return elemType, single, nil
+}
+
+// preloadSubTraversals builds a sub traversal for every field in tree which projects the neighbouring
+// vertices of a vertex of type rt, nested preloads are projected inside the neighbour's map
+func preloadSubTraversals(
+	rt reflect.Type,
+	tree preloadTree,
+) (map[string]*gremlingo.GraphTraversal, error) {
+	subTraversals := make(map[string]*gremlingo.GraphTraversal, len(tree))
+	for name, children := range tree {
+		field, ok := rt.FieldByName(name)
+		if !ok {
+			return nil, fmt.Errorf("preload field %s not found on %s", name, rt.Name())
+		}
+		tag := field.Tag.Get(gsmtypes.GremlinEdgeTag)
+		if tag == "" {
+			return nil, fmt.Errorf("preload field %s has no %s tag", name, gsmtypes.GremlinEdgeTag)
+		}
+		edge, err := parseEdgeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		elemType, single, err := preloadFieldType(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("preload field %s: %w", name, err)
+		}
+		neighbour, ok := reflect.New(elemType).Interface().(gsmtypes.VertexType)
+		if !ok {
+			return nil, fmt.Errorf("preload field %s must hold a vertex type", name)
+		}
+		nested, err := preloadSubTraversals(elemType, children)
+		if err != nil {
+			return nil, err
+		}
+		query := edge.direction.step(newAnonymousTraversal(), edge.label).
+			HasLabel(getLabelFromVertex(neighbour))
+		if embedsSoftDelete(elemType) {
+			query = query.HasNot(gsmtypes.DeletedAt)
+		}
+		if single {
+			query = query.Limit(1)
+		}
+		subTraversals[name] = ToMapTraversal(query, nested, true).Fold()
+	}
+	return subTraversals, nil
+}
+
+// unloadPreloadedField unloads the folded list of neighbour maps into a slice or pointer field
+func unloadPreloadedField(field reflect.Value, preloaded any) error {
+	items, ok := preloaded.([]any)
+	if !ok {
+		return errors.New("preloaded value is not a list")
+	}
+	fieldType := field.Type()
+	if fieldType.Kind() == reflect.Ptr {
+		if len(items) == 0 {
+			field.Set(reflect.Zero(fieldType))
+			return nil
+		}
+		item, err := unloadPreloadedItem(fieldType.Elem(), items[0])
+		if err != nil {
+			return err
+		}
+		field.Set(item)
+		return nil
+	}
+	elemType := fieldType.Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+	slice := reflect.MakeSlice(fieldType, len(items), len(items))
+	for i, raw := range items {
+		item, err := unloadPreloadedItem(structType, raw)
+		if err != nil {
+			return err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			slice.Index(i).Set(item)
+		} else {
+			slice.Index(i).Set(item.Elem())
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// unloadPreloadedItem unloads a single neighbour map into a new pointer to structType
+func unloadPreloadedItem(structType reflect.Type, raw any) (reflect.Value, error) {
+	stringMap, err := toStringMap(raw)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	item := reflect.New(structType)
+	if err = recursivelyUnloadIntoStruct(item.Interface(), stringMap); err != nil {
+		return reflect.Value{}, err
+	}
+	return item, nil
+}
@@ -0,0 +1,220 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testPart struct {
+	gsmtypes.Vertex
+	Name string `json:"name" gremlin:"name"`
+}
+
+type testCar struct {
+	gsmtypes.Vertex
+	Name  string      `json:"name"  gremlin:"name"`
+	Parts []*testPart `json:"parts" gremlinEdge:"has_part"`
+}
+
+type testOwner struct {
+	gsmtypes.Vertex
+	Name     string     `json:"name"     gremlin:"name"`
+	Cars     []testCar  `json:"cars"     gremlinEdge:"owns,out"`
+	Favorite *testCar   `json:"favorite" gremlinEdge:"favorite,out"`
+	Owner    *testOwner `json:"owner"    gremlinEdge:"owns,in"`
+}
+
+func TestPreloadBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestPreloadTree", func(t *testing.T) {
+			t.Parallel()
+			tree := newPreloadTree([]string{"Cars", "Cars.Parts", "Favorite"})
+			expected := preloadTree{"Cars": {"Parts": {}}, "Favorite": {}}
+			if !reflect.DeepEqual(tree, expected) {
+				t.Errorf("Expected %v, got %v", expected, tree)
+			}
+		},
+	)
+	edgeTagTests := []struct {
+		tag       string
+		expected  edgeTag
+		shouldErr bool
+	}{
+		{tag: "owns", expected: edgeTag{label: "owns", direction: Out}},
+		{tag: "owns,out", expected: edgeTag{label: "owns", direction: Out}},
+		{tag: "owns,in", expected: edgeTag{label: "owns", direction: In}},
+		{tag: "owns,both", expected: edgeTag{label: "owns", direction: Both}},
+		{tag: "owns,cascade", expected: edgeTag{label: "owns", direction: Out, cascade: true}},
+		{tag: "owns,out,cascade", expected: edgeTag{label: "owns", direction: Out, cascade: true}},
+		{tag: "owns,in,cascade", shouldErr: true},
+		{tag: "owns,sideways", shouldErr: true},
+		{tag: ",in", shouldErr: true},
+	}
+	for _, tt := range edgeTagTests {
+		t.Run(
+			"TestParseEdgeTag_"+tt.tag, func(t *testing.T) {
+				t.Parallel()
+				parsed, err := parseEdgeTag(tt.tag)
+				if (err != nil) != tt.shouldErr {
+					t.Fatalf("parseEdgeTag() error = %v, shouldErr %v", err, tt.shouldErr)
+				}
+				if parsed != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, parsed)
+				}
+			},
+		)
+	}
+	t.Run(
+		"TestPreloadSubTraversalPointer", func(t *testing.T) {
+			t.Parallel()
+			subTraversals, err := preloadSubTraversals(
+				reflect.TypeFor[testOwner](),
+				newPreloadTree([]string{"Owner"}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "g.in('owns').hasLabel('test_owner').limit(1)" +
+				".valueMap(true).by(choose(count(local).is(eq(1)),unfold(),identity())).fold()"
+			if got := translate(t, subTraversals["Owner"]); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		},
+	)
+	t.Run(
+		"TestPreloadSubTraversalNested", func(t *testing.T) {
+			t.Parallel()
+			subTraversals, err := preloadSubTraversals(
+				reflect.TypeFor[testOwner](),
+				newPreloadTree([]string{"Cars.Parts"}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "g.out('owns').hasLabel('test_car').local(union(" +
+				"valueMap(true).by(choose(count(local).is(eq(1)),unfold(),identity()))," +
+				"project('Parts').by(out('has_part').hasLabel('test_part')" +
+				".valueMap(true).by(choose(count(local).is(eq(1)),unfold(),identity())).fold()))" +
+				".unfold().group().by(keys).by(select(values))).fold()"
+			if got := translate(t, subTraversals["Cars"]); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		},
+	)
+	preloadErrTests := []struct {
+		testName string
+		path     string
+	}{
+		{testName: "TestPreloadUnknownField", path: "Trucks"},
+		{testName: "TestPreloadFieldWithoutTag", path: "Name"},
+		{testName: "TestPreloadUnknownNestedField", path: "Cars.Wheels"},
+	}
+	for _, tt := range preloadErrTests {
+		t.Run(
+			tt.testName, func(t *testing.T) {
+				t.Parallel()
+				_, err := Model[testOwner](db).Preload(tt.path).mapTraversal(db.g.V())
+				if err == nil {
+					t.Errorf("Expected error preloading %s", tt.path)
+				}
+			},
+		)
+	}
+	t.Run(
+		"TestUnloadPreloadedFields", func(t *testing.T) {
+			t.Parallel()
+			var owner testOwner
+			err := UnloadGremlinResultIntoStruct(
+				&owner, &gremlingo.Result{
+					Data: map[any]any{
+						"id":   "1",
+						"name": "owner",
+						"Cars": []any{
+							map[any]any{
+								"id":    "2",
+								"name":  "car",
+								"Parts": []any{map[any]any{"id": "3", "name": "wheel"}},
+							},
+						},
+						"Favorite": []any{map[any]any{"id": "2", "name": "car"}},
+						"Owner":    []any{},
+					},
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(owner.Cars) != 1 || owner.Cars[0].Name != "car" {
+				t.Fatalf("Expected one car, got %v", owner.Cars)
+			}
+			if len(owner.Cars[0].Parts) != 1 || owner.Cars[0].Parts[0].Name != "wheel" {
+				t.Errorf("Expected one wheel part, got %v", owner.Cars[0].Parts)
+			}
+			if owner.Favorite == nil || owner.Favorite.ID != "2" {
+				t.Errorf("Expected favorite car with id 2, got %v", owner.Favorite)
+			}
+			if owner.Owner != nil {
+				t.Errorf("Expected no owner, got %v", owner.Owner)
+			}
+		},
+	)
+	t.Run(
+		"TestUnloadPreloadedFieldNotList", func(t *testing.T) {
+			t.Parallel()
+			var owner testOwner
+			err := UnloadGremlinResultIntoStruct(
+				&owner, &gremlingo.Result{Data: map[any]any{"Cars": "not a list"}},
+			)
+			if err == nil {
+				t.Error("Expected error unloading a preloaded field which is not a list")
+			}
+		},
+	)
+}
+
+func TestPreload(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run(
+		"TestPreloadFind", func(t *testing.T) {
+			t.Cleanup(cleanDB)
+			owner := testOwner{Name: "owner"}
+			car := testCar{Name: "car"}
+			part := testPart{Name: "wheel"}
+			for _, err = range []error{Create(db, &owner), Create(db, &car), Create(db, &part)} {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err = <-db.g.V(owner.ID).AddE("owns").To(gremlingo.T__.V(car.ID)).Iterate(); err != nil {
+				t.Fatal(err)
+			}
+			if err = <-db.g.V(car.ID).AddE("has_part").To(gremlingo.T__.V(part.ID)).Iterate(); err != nil {
+				t.Fatal(err)
+			}
+			result, err := Model[testOwner](db).
+				Where("name", comparator.EQ, "owner").
+				Preload("Cars.Parts").
+				Take()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Cars) != 1 || len(result.Cars[0].Parts) != 1 {
+				t.Fatalf("Expected one car with one part, got %v", result.Cars)
+			}
+			if result.Cars[0].Parts[0].Name != "wheel" {
+				t.Errorf("Expected wheel, got %s", result.Cars[0].Parts[0].Name)
+			}
+		},
+	)
+}
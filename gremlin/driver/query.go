@@ -1,10 +1,13 @@
 package driver
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -25,59 +28,25 @@ type Query[T gsmtypes.VertexType] struct {
 	limit         *int
 	offset        *int
 	subTraversals map[string]*gremlingo.GraphTraversal
-	orderBy       *OrderCondition
+	preloads      []string
+	orderBy       []*OrderCondition
 	dedup         bool
-	debugString   *strings.Builder
+	// softDelete is set when T embeds SoftDelete, soft deleted vertices are skipped unless the query is unscoped
+	softDelete  bool
+	unscoped    bool
+	debugString *strings.Builder
+	// errs holds the problems found while the query was built, they are returned before anything is sent
+	errs []error
+	// start builds the traversal this query continues from, nil when the query starts at g.V()
+	start func() (*gremlingo.GraphTraversal, error)
 }
 
-type QueryCondition struct {
+// OrderCondition is a single key of the order step
+// the key is the traversal when set, otherwise the field, an empty field with Shuffle shuffles the results
+type OrderCondition struct {
 	field     string
-	operator  comparator.Comparator
-	value     any
 	traversal *gremlingo.GraphTraversal
-}
-
-func (qc *QueryCondition) String() string {
-	if qc.traversal != nil {
-		return ""
-	}
-
-	if qc.field == "id" {
-		return fmt.Sprintf(".HasId(%v)", qc.value)
-	}
-	var sb strings.Builder
-	sb.WriteString(".Has(")
-	sb.WriteString(qc.field)
-	sb.WriteString(", ")
-
-	switch qc.operator {
-	case comparator.EQ, "eq":
-		sb.WriteString("P.Eq(")
-	case comparator.NEQ, "neq":
-		sb.WriteString("P.Neq(")
-	case comparator.GT, "gt":
-		sb.WriteString("P.Gt(")
-	case comparator.GTE, "gte":
-		sb.WriteString("P.Gte(")
-	case comparator.LT, "lt":
-		sb.WriteString("P.Lt(")
-	case comparator.LTE, "lte":
-		sb.WriteString("P.Lte(")
-	case comparator.IN:
-		sb.WriteString("P.Within(")
-	case comparator.CONTAINS:
-		sb.WriteString("TextP.Containing(")
-	case comparator.WITHOUT:
-		sb.WriteString("P.Without(")
-	}
-
-	sb.WriteString(fmt.Sprintf("%v))", qc.value))
-	return sb.String()
-}
-
-type OrderCondition struct {
-	field string
-	desc  bool
+	order     GremlinOrder
 }
 
 func getLabel[T gsmtypes.VertexType]() (string, error) {
@@ -89,7 +58,15 @@ func getLabel[T gsmtypes.VertexType]() (string, error) {
 
 // NewQuery creates a new query builder for type T
 func NewQuery[T gsmtypes.VertexType](db *GremlinDriver) *Query[T] {
-	label, _ := getLabel[T]()
+	errs := make([]error, 0)
+	var label string
+	if db.registry == nil {
+		label, _ = getLabel[T]()
+	} else if info, ok := db.registry.modelOf(reflect.TypeFor[T]()); ok {
+		label = info.Label
+	} else {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrModelNotRegistered, reflect.TypeFor[T]()))
+	}
 	queryAsString := strings.Builder{}
 	queryAsString.WriteString("V()")
 	if label != "" {
@@ -104,8 +81,9 @@ func NewQuery[T gsmtypes.VertexType](db *GremlinDriver) *Query[T] {
 		ids:           ids,
 		conditions:    make([]*QueryCondition, 0),
 		label:         label,
-		orderBy:       nil,
 		subTraversals: make(map[string]*gremlingo.GraphTraversal),
+		softDelete:    embedsSoftDelete(reflect.TypeFor[T]()),
+		errs:          errs,
 	}
 }
 
@@ -128,30 +106,57 @@ func (q *Query[T]) AddSubTraversal(
 	return q
 }
 
-// Where adds a condition to the query
-func (q *Query[T]) Where(field string, operator comparator.Comparator, value any) *Query[T] {
-	queryCondition := QueryCondition{
-		field:    field,
-		operator: operator,
-		value:    value,
+// Preload eagerly loads the related vertices of the fields tagged with gremlinEdge
+// nested relations can be preloaded by separating field names with a dot e.g. "Cars.Parts"
+func (q *Query[T]) Preload(fields ...string) *Query[T] {
+	for _, field := range fields {
+		q.writeDebugString(".Preload(" + field + ")")
 	}
-	q.writeDebugString(queryCondition.String())
-
-	q.conditions = append(
-		q.conditions, &queryCondition,
-	)
+	q.preloads = append(q.preloads, fields...)
 	return q
 }
 
+// Where adds a condition to the query
+func (q *Query[T]) Where(field string, operator comparator.Comparator, value any) *Query[T] {
+	return q.addCondition(Cond(field, operator, value))
+}
+
 // WhereTraversal adds a custom Gremlin traversal condition
 func (q *Query[T]) WhereTraversal(traversal *gremlingo.GraphTraversal) *Query[T] {
-	queryCondition := QueryCondition{
-		traversal: traversal,
+	return q.addCondition(&QueryCondition{traversal: traversal})
+}
+
+// Or adds a condition group which matches when any of the conditions match
+// conditions are created with Cond and can be nested with the package level Or, And and Not
+func (q *Query[T]) Or(conditions ...*QueryCondition) *Query[T] {
+	return q.addCondition(Or(conditions...))
+}
+
+// And adds a condition group which matches when all of the conditions match
+// this is useful for nesting inside Or, at the top level Where already ands conditions together
+func (q *Query[T]) And(conditions ...*QueryCondition) *Query[T] {
+	return q.addCondition(And(conditions...))
+}
+
+// Not adds a condition which matches when the given condition does not match
+func (q *Query[T]) Not(condition *QueryCondition) *Query[T] {
+	return q.addCondition(Not(condition))
+}
+
+// addCondition validates the condition against the gremlin tags of T and adds it to the query
+// invalid conditions are recorded and returned by the terminal operations
+func (q *Query[T]) addCondition(condition *QueryCondition) *Query[T] {
+	if err := validateCondition(condition, gremlinFieldNames(reflect.TypeFor[T]())); err != nil {
+		q.errs = append(q.errs, err)
 	}
-	q.writeDebugString(queryCondition.String())
-	q.conditions = append(
-		q.conditions, &queryCondition,
-	)
+	q.writeDebugString(condition.String())
+	q.conditions = append(q.conditions, condition)
+	return q
+}
+
+// WithContext binds the query to ctx, see GremlinDriver.WithContext
+func (q *Query[T]) WithContext(ctx context.Context) *Query[T] {
+	q.db = q.db.WithContext(ctx)
 	return q
 }
 
@@ -194,32 +199,44 @@ func (q *Query[T]) Offset(offset int) *Query[T] {
 	return q
 }
 
-// OrderBy adds ordering to the query
+// OrderBy adds an order key to the query, calling it again adds a secondary key
+// ordering by "id" orders by the element id
 func (q *Query[T]) OrderBy(field string, order GremlinOrder) *Query[T] {
-	if q.orderBy != nil {
-		q.db.logger.Warn(
-			"Order by was already defined secondary order by will override original order",
-		)
+	q.writeDebugString(".OrderBy(" + field + ", " + order.String() + ")")
+	if err := validateOrderField(field, gremlinFieldNames(reflect.TypeFor[T]())); err != nil {
+		q.errs = append(q.errs, err)
 	}
-	q.writeDebugString(".OrderBy(")
-	q.writeDebugString(field)
-	q.writeDebugString(", ")
-	if order == Desc {
-		q.writeDebugString("Order.Desc")
-	} else {
-		q.writeDebugString("Order.Asc")
-	}
-	q.writeDebugString(")")
-	desc := order != 0
-	q.orderBy = &OrderCondition{field: field, desc: desc}
+	q.orderBy = append(q.orderBy, &OrderCondition{field: field, order: order})
+	return q
+}
+
+// OrderByTraversal adds an order key using the value of the traversal for each result
+// e.g. anonymousTraversal.OutE("owns").Count() orders by the number of owns edges
+func (q *Query[T]) OrderByTraversal(traversal *gremlingo.GraphTraversal, order GremlinOrder) *Query[T] {
+	q.writeDebugString(".OrderByTraversal(" + order.String() + ")")
+	q.orderBy = append(q.orderBy, &OrderCondition{traversal: traversal, order: order})
+	return q
+}
+
+// Shuffle orders the results randomly, keys added before it take precedence
+func (q *Query[T]) Shuffle() *Query[T] {
+	q.writeDebugString(".Shuffle()")
+	q.orderBy = append(q.orderBy, &OrderCondition{order: Shuffle})
 	return q
 }
 
 // Find executes the query and returns all matching results
 func (q *Query[T]) Find() ([]T, error) {
 	q.writeDebugString(".ToList()")
-	query := q.BuildQuery()
-	queryResults, err := ToMapTraversal(query, q.subTraversals, true).ToList()
+	query, err := q.build()
+	if err != nil {
+		return nil, err
+	}
+	query, err = q.mapTraversal(query)
+	if err != nil {
+		return nil, err
+	}
+	queryResults, err := await(q.db, query.ToList)
 	if err != nil {
 		return nil, err
 	}
@@ -228,6 +245,9 @@ func (q *Query[T]) Find() ([]T, error) {
 	for _, result := range queryResults {
 		var v T
 		err = UnloadGremlinResultIntoStruct(&v, result)
+		if err == nil {
+			err = afterFind(&v)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -240,21 +260,29 @@ func (q *Query[T]) Find() ([]T, error) {
 func (q *Query[T]) Take() (T, error) {
 	q.writeDebugString(".Next()")
 	var v T
-	query := q.BuildQuery()
-	result, err := ToMapTraversal(query, q.subTraversals, true).Next()
+	query, err := q.build()
 	if err != nil {
 		return v, err
 	}
-
-	err = UnloadGremlinResultIntoStruct(&v, result)
-	return v, err
+	query, err = q.mapTraversal(query)
+	if err != nil {
+		return v, err
+	}
+	result, err := await(q.db, query.Next)
+	if err != nil {
+		return v, err
+	}
+	return unloadFound[T](result)
 }
 
 // Count returns the number of matching results
 func (q *Query[T]) Count() (int, error) {
 	q.writeDebugString(".Count()")
-	query := q.BuildQuery()
-	result, err := query.Count().Next()
+	query, err := q.build()
+	if err != nil {
+		return 0, err
+	}
+	result, err := await(q.db, query.Count().Next)
 	if err != nil {
 		return 0, err
 	}
@@ -265,81 +293,230 @@ func (q *Query[T]) Count() (int, error) {
 	return num, nil
 }
 
-// Delete deletes all matching results
+// Delete deletes all matching results, models embedding SoftDelete are only marked deleted unless the query is Unscoped
+// vertices owned through gremlinEdge fields tagged cascade are dropped in the same traversal, see DryRunDelete
+// when the model implements BeforeDeleter or AfterDeleter the matching vertices are loaded first
+// and nothing is deleted when a BeforeDelete hook fails
 func (q *Query[T]) Delete() error {
-	q.writeDebugString(".Drop().Iterate()")
-	query := q.BuildQuery()
-	err := query.Drop().Iterate()
-	return <-err
+	if q.softDelete && !q.unscoped {
+		q.writeDebugString(".Property(deleted_at).Iterate()")
+		return q.delete(softDeleteVertices(time.Now().UTC()))
+	}
+	return q.HardDelete()
+}
+
+func (q *Query[T]) delete(remove func(*gremlingo.GraphTraversal) *gremlingo.GraphTraversal) error {
+	query, err := q.build()
+	if err != nil {
+		return err
+	}
+	if hasDeleteHooks[T]() {
+		return q.deleteWithHooks(query, remove)
+	}
+	return awaitIterate(q.db, remove(query).Iterate)
+}
+
+func (q *Query[T]) deleteWithHooks(
+	query *gremlingo.GraphTraversal,
+	remove func(*gremlingo.GraphTraversal) *gremlingo.GraphTraversal,
+) error {
+	query, err := q.mapTraversal(query)
+	if err != nil {
+		return err
+	}
+	queryResults, err := await(q.db, query.ToList)
+	if err != nil {
+		return err
+	}
+	matched := make([]*T, 0, len(queryResults))
+	ids := make([]any, 0, len(queryResults))
+	for _, result := range queryResults {
+		v := new(T)
+		if err = UnloadGremlinResultIntoStruct(v, result); err != nil {
+			return err
+		}
+		if err = callHook(v, gsmtypes.BeforeDeleter.BeforeDelete); err != nil {
+			return err
+		}
+		matched = append(matched, v)
+		ids = append(ids, (*v).GetVertexID())
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err = awaitIterate(q.db, remove(q.db.g.V(ids...)).Iterate); err != nil {
+		return err
+	}
+	// the vertices are already deleted so every AfterDelete hook is called even when one of them fails
+	var errs []error
+	for _, v := range matched {
+		if hookErr := callHook(v, gsmtypes.AfterDeleter.AfterDelete); hookErr != nil {
+			errs = append(errs, hookErr)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // ID finds vertex by id in a more optimized way than using where
+// the problems recorded while the query was built, e.g. a model missing from the registry, are returned first
 func (q *Query[T]) ID(id any) (T, error) {
 	var v T
-	query := q.db.g.V(id)
-	label, err := getLabel[T]()
+	if len(q.errs) > 0 {
+		return v, newValidationError(errors.Join(q.errs...))
+	}
+	var query *gremlingo.GraphTraversal
+	if q.start != nil {
+		start, err := q.start()
+		if err != nil {
+			return v, newValidationError(err)
+		}
+		query = start.HasId(id)
+	} else {
+		query = q.db.g.V(id)
+	}
+	if q.label != "" {
+		query = query.HasLabel(q.label)
+	}
+	if q.softDelete && !q.unscoped {
+		query = query.HasNot(gsmtypes.DeletedAt)
+	}
+	query, err := q.mapTraversal(query)
 	if err != nil {
 		return v, err
 	}
-	query = query.HasLabel(label)
-	result, err := ToMapTraversal(query, q.subTraversals, true).Next()
+	result, err := await(q.db, query.Next)
 	if err != nil {
 		return v, err
 	}
-	err = UnloadGremlinResultIntoStruct(&v, result)
-	return v, err
+	return unloadFound[T](result)
+}
+
+// unloadFound unloads a single result and calls its AfterFind hook
+func unloadFound[T gsmtypes.VertexType](result *gremlingo.Result) (T, error) {
+	var v T
+	if err := UnloadGremlinResultIntoStruct(&v, result); err != nil {
+		return v, err
+	}
+	return v, afterFind(&v)
 }
 
-// Update updates a property of the struct
-// NOTE: Slices will be updated as Cardinality.Set
-// NOTE: Maps will be updated as Cardinality.Set with keys as the value of the property
+// Update sets a property of every matched vertex, value is converted like the field of the struct
+// slice fields replace the stored values using the cardinality of their tag, see fieldCardinality
+// nested struct and map fields replace all of their prefixed properties, e.g. labels.team
+// readonly fields cannot be updated and required fields cannot be set to their zero value
+// the version field of T is incremented on every matched vertex, see versionIncrement
 func (q *Query[T]) Update(propertyName string, value any) error {
-	// figure out if propertyName is in the struct
-	_, fieldType, err := getStructFieldNameAndType[T](propertyName)
+	fieldType, tag, propertyCardinality, multi, err := q.updateField(propertyName, value)
 	if err != nil {
-		return fmt.Errorf("propertyName not found in gremlin struct tags: %s", propertyName)
+		return newValidationError(err)
+	}
+	query, err := q.build()
+	if err != nil {
+		return err
 	}
-	query := q.BuildQuery()
 	query.Property(cardinality.Single, gsmtypes.LastModified, time.Now().UTC())
-	switch fieldType.Kind() { //nolint: exhaustive // We are only handling slices and maps otherwise regular cardinality
-	case reflect.Slice:
-		cardinality := gremlingo.Cardinality.List
-		cardinalityString := "Cardinality.List"
-		if q.db.dbDriver == Neptune {
-			cardinalityString = "Cardinality.Set"
-			cardinality = gremlingo.Cardinality.Set
+	versionName, increment, err := versionIncrement(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+	if versionName != "" && versionName != propertyName {
+		q.writeDebugString(".Property(Cardinality.Single, " + versionName + ", Union(Values(" + versionName +
+			"), Constant(1)).Sum())")
+		query = query.Property(cardinality.Single, versionName, increment)
+	}
+	if multi {
+		values, valuesErr := propertyValues(value)
+		if valuesErr != nil {
+			return fmt.Errorf("error encoding property %s: %w", propertyName, valuesErr)
 		}
-		sliceValue, _ := value.([]any)
-		for _, v := range sliceValue {
-			q.writeDebugString(".Property(")
-			q.writeDebugString(cardinalityString)
-			q.writeDebugString(", ")
-			q.writeDebugString(propertyName)
-			q.writeDebugString(", ")
-			q.writeDebugString(fmt.Sprintf("%v", v))
-			q.writeDebugString(")")
-			query = query.Property(cardinality, propertyName, v)
+		q.writeDebugString(".SideEffect(Properties(" + propertyName + ").Drop())")
+		for _, v := range values {
+			q.writeDebugString(fmt.Sprintf(".Property(Cardinality.%v, %s, %v)", propertyCardinality, propertyName, v))
+		}
+		query = multiValue{key: propertyName, cardinality: propertyCardinality, values: values}.write(query)
+		return awaitIterate(q.db, query.Iterate)
+	}
+
+	properties := make(map[string]any)
+	if value == nil {
+		properties[propertyName] = nil
+	} else if err = addFieldProperty(propertyName, tag, reflect.ValueOf(value), properties, nil); err != nil {
+		return err
+	}
+	if !tag.json && (isNestedStruct(fieldType) || isStringMap(fieldType)) {
+		prefix := propertyName + nestedFieldSeparator
+		q.writeDebugString(".SideEffect(Properties().HasKey(StartingWith(" + prefix + ")).Drop())")
+		query = query.SideEffect(
+			anonymousTraversal.Properties().HasKey(gremlingo.TextP.StartingWith(prefix)).Drop(),
+		)
+	}
+	for _, key := range slices.Sorted(maps.Keys(properties)) {
+		if properties[key] == nil {
+			q.writeDebugString(".SideEffect(Properties(" + key + ").Drop())")
+			query = query.SideEffect(anonymousTraversal.Properties(key).Drop())
+			continue
+		}
+		q.writeDebugString(fmt.Sprintf(".Property(Cardinality.Single, %s, %v)", key, properties[key]))
+		query = query.Property(cardinality.Single, key, properties[key])
+	}
+	return awaitIterate(q.db, query.Iterate)
+}
+
+// updateField returns the type, tag and cardinality of the field updated by Update
+// the errors of an invalid update are returned joined, they are not kept on the query so it can be reused
+func (q *Query[T]) updateField(propertyName string, value any) (reflect.Type, gremlinTag, any, bool, error) {
+	// figure out if propertyName is in the struct
+	field, ok := schemaOf(reflect.TypeFor[T]()).property(propertyName)
+	if !ok {
+		return nil, gremlinTag{}, nil, false, fmt.Errorf(
+			"propertyName not found in gremlin struct tags: %s", propertyName,
+		)
+	}
+	fieldType := reflect.TypeFor[T]().FieldByIndex(field.index).Type
+	if err := validateUpdateValue(fieldType, propertyName, value); err != nil {
+		return nil, gremlinTag{}, nil, false, err
+	}
+	var errs []error
+	tag := field.tag
+	switch {
+	case tag.readOnly:
+		errs = append(errs, fmt.Errorf("property %s is read only", propertyName))
+	case tag.required && (value == nil || reflect.ValueOf(value).IsZero()):
+		errs = append(errs, fmt.Errorf("%w: %s", ErrRequiredField, propertyName))
+	}
+	propertyCardinality, multi, err := fieldCardinality(q.db.dbDriver, fieldType, tag)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("property %s: %w", propertyName, err))
+	}
+	return fieldType, tag, propertyCardinality, multi, errors.Join(errs...)
+}
+
+// validateUpdateValue checks that slice and map fields are updated with a slice or map value
+func validateUpdateValue(fieldType reflect.Type, propertyName string, value any) error {
+	switch fieldType.Kind() { //nolint: exhaustive // only slices and maps are written per element
+	case reflect.Slice:
+		if _, ok := toAnySlice(value); !ok {
+			return fmt.Errorf("property %s expects a slice value, got %T", propertyName, value)
 		}
 	case reflect.Map:
-		mapValue, _ := value.(map[any]any)
-		for k := range mapValue {
-			q.writeDebugString(".Property(Cardinality.Set, ")
-			q.writeDebugString(propertyName)
-			q.writeDebugString(", ")
-			q.writeDebugString(fmt.Sprintf("%v", k))
-			q.writeDebugString(")")
-			query = query.Property(gremlingo.Cardinality.Set, propertyName, k)
+		if reflect.ValueOf(value).Kind() != reflect.Map {
+			return fmt.Errorf("property %s expects a map value, got %T", propertyName, value)
 		}
-	default:
-		q.writeDebugString(".Property(Cardinality.Single, ")
-		q.writeDebugString(propertyName)
-		q.writeDebugString(", ")
-		q.writeDebugString(fmt.Sprintf("%v", value))
-		q.writeDebugString(")")
-		query = query.Property(gremlingo.Cardinality.Single, propertyName, value)
 	}
-	errChan := query.Iterate()
-	return <-errChan
+	return nil
+}
+
+// mapTraversal converts the query into a map traversal projecting the sub traversals and preloaded fields
+func (q *Query[T]) mapTraversal(query *gremlingo.GraphTraversal) (*gremlingo.GraphTraversal, error) {
+	if len(q.preloads) == 0 {
+		return ToMapTraversal(query, q.subTraversals, true), nil
+	}
+	preloads, err := preloadSubTraversals(reflect.TypeFor[T](), newPreloadTree(q.preloads))
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(preloads, q.subTraversals)
+	return ToMapTraversal(query, preloads, true), nil
 }
 
 // writeDebugString writes a string to the debug string if GSM_DEBUG is set to true
@@ -350,35 +527,68 @@ func (q *Query[T]) writeDebugString(s string) {
 }
 
 // BuildQuery constructs the Gremlin traversal from the query conditions
+// when the query is invalid the errors are logged and the traversal ends in limit(0) so it matches nothing,
+// e.g. a misspelt field followed by Drop() drops nothing instead of every element of the label
+// the terminal operations such as Find and Delete return a ValidationError instead of running the query
 func (q *Query[T]) BuildQuery() *gremlingo.GraphTraversal {
+	query, err := q.build()
+	if err != nil {
+		q.db.logger.Errorf("Failed to build query: %v", err)
+		return query.Limit(0)
+	}
+	return query
+}
+
+// build logs the debug string and constructs the Gremlin traversal
+func (q *Query[T]) build() (*gremlingo.GraphTraversal, error) {
 	if os.Getenv("GSM_DEBUG") == "true" {
 		q.db.logger.Infof("Running Query: %s", q.debugString.String())
 		q.debugString.Reset()
 	}
+	query, err := q.buildTraversal()
+	if err != nil {
+		return query, newValidationError(err)
+	}
+	return query, nil
+}
+
+// buildTraversal constructs the Gremlin traversal without logging the debug string
+// the traversal is always returned, problems recorded while building the query are reported in the error
+func (q *Query[T]) buildTraversal() (*gremlingo.GraphTraversal, error) {
 	var query *gremlingo.GraphTraversal
-	if len(q.ids) > 0 {
+	var err error
+	switch {
+	case q.start != nil:
+		query, err = q.start()
+		if len(q.ids) > 0 {
+			query = query.HasId(q.ids...)
+		}
+	case len(q.ids) > 0:
 		query = q.db.g.V(q.ids...)
-	} else {
+	default:
 		query = q.db.g.V()
 	}
 
 	if q.label != "" {
 		query = query.HasLabel(q.label)
 	}
+	if q.softDelete && !q.unscoped {
+		query = query.HasNot(gsmtypes.DeletedAt)
+	}
 
-	q.addQueryConditions(query)
+	// conditions are validated when added so compile errors are only reported when nothing was recorded
+	conditionErr := addQueryConditions(query, q.conditions)
+	if len(q.errs) > 0 {
+		err = errors.Join(err, errors.Join(q.errs...))
+	} else {
+		err = errors.Join(err, conditionErr)
+	}
 
 	if q.dedup {
 		query = query.Dedup()
 	}
 
-	if q.orderBy != nil {
-		if q.orderBy.desc {
-			query.Order().By(q.orderBy.field, Order.Desc)
-		} else {
-			query.Order().By(q.orderBy.field, Order.Asc)
-		}
-	}
+	addOrderCondition(query, q.orderBy)
 
 	// Apply offset
 	if q.offset != nil {
@@ -390,45 +600,33 @@ func (q *Query[T]) BuildQuery() *gremlingo.GraphTraversal {
 		query = query.Limit(*q.limit)
 	}
 
-	return query
+	return query, err
 }
 
-func (q *Query[T]) addQueryConditions(query *gremlingo.GraphTraversal) {
-	// Apply conditions
-	for _, condition := range q.conditions {
-		if condition.traversal != nil {
-			query = query.Where(condition.traversal)
-			continue
-		}
-		switch condition.operator {
-		case comparator.EQ, "eq":
-			if condition.field == "id" {
-				query = query.HasId(condition.value)
-			} else {
-				query = query.Has(condition.field, condition.value)
-			}
-		case comparator.NEQ, "neq":
-			query = query.Has(condition.field, gremlingo.P.Neq(condition.value))
-		case comparator.GT, "gt":
-			query = query.Has(condition.field, gremlingo.P.Gt(condition.value))
-		case comparator.GTE, "gte":
-			query = query.Has(condition.field, gremlingo.P.Gte(condition.value))
-		case comparator.LT, "lt":
-			query = query.Has(condition.field, gremlingo.P.Lt(condition.value))
-		case comparator.LTE, "lte":
-			query = query.Has(condition.field, gremlingo.P.Lte(condition.value))
-		case comparator.IN:
-			if slice, ok := condition.value.([]any); ok {
-				query = query.Has(condition.field, gremlingo.P.Within(slice...))
-			}
-		case comparator.CONTAINS:
-			if strVal, ok := condition.value.(string); ok {
-				query = query.Has(condition.field, gremlingo.TextP.Containing(strVal))
-			}
-		case comparator.WITHOUT:
-			if slice, ok := condition.value.([]any); ok {
-				query = query.Has(condition.field, gremlingo.P.Without(slice...))
-			}
+// validateOrderField checks that the field ordered by is a gremlin tag of the model or the id
+func validateOrderField(field string, fields map[string]struct{}) error {
+	if !hasGremlinField(fields, field) && field != "id" {
+		return fmt.Errorf("order field %s is not a gremlin tag of the model", field)
+	}
+	return nil
+}
+
+// addOrderCondition appends a single order step with a by modulator per key, nothing is added without keys
+func addOrderCondition(query *gremlingo.GraphTraversal, orderBy []*OrderCondition) {
+	if len(orderBy) == 0 {
+		return
+	}
+	query.Order()
+	for _, condition := range orderBy {
+		switch {
+		case condition.traversal != nil:
+			query.By(condition.traversal, condition.order.gremlinOrder())
+		case condition.field == "id":
+			query.By(gremlingo.T.Id, condition.order.gremlinOrder())
+		case condition.field == "":
+			query.By(condition.order.gremlinOrder())
+		default:
+			query.By(condition.field, condition.order.gremlinOrder())
 		}
 	}
 }
@@ -457,7 +655,7 @@ func ToMapTraversal(
 		)
 	}
 	subtraversalsKeys := make([]any, 0, len(subtraversals))
-	for key := range subtraversals {
+	for _, key := range slices.Sorted(maps.Keys(subtraversals)) {
 		subtraversalsKeys = append(subtraversalsKeys, key)
 	}
 	projectQuery := anonymousTraversal.Project(subtraversalsKeys...)
@@ -1,10 +1,15 @@
 package driver
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
 	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
 )
 
 func seedData(db *GremlinDriver, data []testVertexForUtils) error {
@@ -89,7 +94,7 @@ func TestQuery(t *testing.T) {
 				}
 				for i, item := range results {
 					var idx int
-					switch orderTest.Order {
+					switch orderTest.Order { //nolint: exhaustive // shuffle is not part of the table
 					case Asc:
 						idx = i
 					case Desc:
@@ -274,6 +279,43 @@ func TestQuery(t *testing.T) {
 			}
 		},
 	)
+	t.Run(
+		"TestUpdateFields", func(t *testing.T) {
+			t.Cleanup(cleanDB)
+			err = seedData(db, seededData)
+			if err != nil {
+				t.Error(err)
+			}
+			model, err := Model[testVertexForUtils](db).Where("name", comparator.EQ, "first").Take()
+			if err != nil {
+				t.Fatal(err)
+			}
+			createdAt := model.CreatedAt
+			model.Name = "fifth"
+			model.Sort = 5
+			model.CreatedAt = time.Now().UTC()
+			err = Update(db, &model, "name")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !model.CreatedAt.Equal(createdAt) {
+				t.Errorf("Expected stored created_at %v, got %v", createdAt, model.CreatedAt)
+			}
+			stored, err := Model[testVertexForUtils](db).ID(model.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Name != "fifth" {
+				t.Errorf("Expected %s result, got %s", "fifth", stored.Name)
+			}
+			if stored.Sort != 1 {
+				t.Errorf("Expected sort not to be updated, got %d", stored.Sort)
+			}
+			if !stored.CreatedAt.Equal(createdAt) {
+				t.Errorf("Expected created_at %v to be kept, got %v", createdAt, stored.CreatedAt)
+			}
+		},
+	)
 	t.Run(
 		"TestQueryIDs", func(t *testing.T) {
 			t.Cleanup(cleanDB)
@@ -320,3 +362,112 @@ func TestQuery(t *testing.T) {
 		},
 	)
 }
+
+func TestQueryOrderBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	tests := []struct {
+		testName string
+		query    *Query[testVertexForUtils]
+		expected string
+	}{
+		{
+			testName: "TestOrderByMultipleKeys",
+			query:    Model[testVertexForUtils](db).OrderBy("name", Asc).OrderBy("created_at", Desc),
+			expected: "g.V().hasLabel('test_vertex_for_utils').order().by('name',asc).by('created_at',desc)",
+		},
+		{
+			testName: "TestOrderByID",
+			query:    Model[testVertexForUtils](db).OrderBy("id", Desc),
+			expected: "g.V().hasLabel('test_vertex_for_utils').order().by(id,desc)",
+		},
+		{
+			testName: "TestOrderByTraversal",
+			query: Model[testVertexForUtils](db).
+				OrderByTraversal(anonymousTraversal.OutE("owns").Count(), Desc).
+				OrderBy("name", Asc),
+			expected: "g.V().hasLabel('test_vertex_for_utils').order().by(outE('owns').count(),desc).by('name',asc)",
+		},
+		{
+			testName: "TestShuffle",
+			query:    Model[testVertexForUtils](db).Where("sort", comparator.GT, 1).Shuffle().Limit(1),
+			expected: "g.V().hasLabel('test_vertex_for_utils').has('sort',gt(1)).order().by(shuffle).limit(1)",
+		},
+		{
+			testName: "TestOrderByShuffleAfterKey",
+			query:    Model[testVertexForUtils](db).OrderBy("sort", Desc).Shuffle(),
+			expected: "g.V().hasLabel('test_vertex_for_utils').order().by('sort',desc).by(shuffle)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.testName, func(t *testing.T) {
+				t.Parallel()
+				if got := translate(t, tt.query.BuildQuery()); got != tt.expected {
+					t.Errorf("Expected %s, got %s", tt.expected, got)
+				}
+			},
+		)
+	}
+	t.Run(
+		"TestOrderByUnknownField", func(t *testing.T) {
+			t.Parallel()
+			var validationErr *ValidationError
+			if _, err := Model[testVertexForUtils](db).OrderBy("nmae", Asc).Find(); !errors.As(err, &validationErr) {
+				t.Errorf("Expected ValidationError, got %v", err)
+			}
+		},
+	)
+}
+
+func TestUpdateValidation(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestUpdateWithoutID", func(t *testing.T) {
+			t.Parallel()
+			if err := Update(db, &testVertexForUtils{Name: "first"}); err == nil {
+				t.Error("Expected error for a value without an id")
+			}
+		},
+	)
+	t.Run(
+		"TestUpdateInvalidFields", func(t *testing.T) {
+			t.Parallel()
+			for _, field := range []string{"nmae", "id", "created_at"} {
+				model := testVertexForUtils{Vertex: gsmtypes.Vertex{ID: 1}, Name: "first"}
+				if err := Update(db, &model, field); err == nil {
+					t.Errorf("Expected error when updating field %s", field)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestUpdateSelectedFields", func(t *testing.T) {
+			t.Parallel()
+			rt := reflect.TypeFor[testVertexForUtils]()
+			properties := map[string]any{"name": "first", "sort": 1}
+			selected, _, err := selectUpdateFields(rt, properties, []string{"name"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(selected) != 1 || selected["name"] != "first" {
+				t.Errorf("Expected only name to be selected, got %v", selected)
+			}
+			if selected, _, _ = selectUpdateFields(rt, properties, nil); len(selected) != len(properties) {
+				t.Errorf("Expected all properties without fields, got %v", selected)
+			}
+		},
+	)
+	t.Run(
+		"TestUpdateIsNotSentWhenCancelled", func(t *testing.T) {
+			t.Parallel()
+			cancelled, cancel := context.WithCancel(context.Background())
+			cancel()
+			model := testVertexForUtils{Vertex: gsmtypes.Vertex{ID: 1}, Name: "first"}
+			if err := Update(db.WithContext(cancelled), &model, "name"); !errors.Is(err, ErrQueryAborted) {
+				t.Errorf("Expected ErrQueryAborted, got %v", err)
+			}
+		},
+	)
+}
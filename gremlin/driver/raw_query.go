@@ -1,6 +1,8 @@
 package driver
 
 import (
+	"context"
+
 	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
 )
 
@@ -11,6 +13,13 @@ type RawQuery struct {
 	traversal *gremlingo.GraphTraversal
 }
 
+// WithContext binds the query to ctx, see GremlinDriver.WithContext
+// the deadline is only sent to the server when WithContext is called before the other builder functions
+func (rq *RawQuery) WithContext(ctx context.Context) *RawQuery {
+	rq.db = rq.db.WithContext(ctx)
+	return rq
+}
+
 func (rq *RawQuery) Where(traversal *gremlingo.GraphTraversal) *RawQuery {
 	if rq.traversal == nil {
 		rq.traversal = rq.db.g.V().HasLabel(rq.label)
@@ -39,7 +48,7 @@ func (rq *RawQuery) ToList() ([]*gremlingo.Result, error) {
 	if rq.traversal == nil {
 		rq.traversal = rq.db.g.V().HasLabel(rq.label)
 	}
-	results, err := rq.traversal.ToList()
+	results, err := await(rq.db, rq.traversal.ToList)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +60,7 @@ func (rq *RawQuery) Next() (*gremlingo.Result, error) {
 	if rq.traversal == nil {
 		rq.traversal = rq.db.g.V().HasLabel(rq.label)
 	}
-	result, err := rq.traversal.ElementMap().Next()
+	result, err := await(rq.db, rq.traversal.ElementMap().Next)
 	if err != nil {
 		return nil, err
 	}
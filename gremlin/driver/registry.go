@@ -0,0 +1,187 @@
+package driver
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// ModelInfo describes a model registered in a Registry
+type ModelInfo struct {
+	Type  reflect.Type
+	Label string
+	Edge  bool
+	// PropertyKeys holds the sorted property names of the model, see Registry.Register
+	PropertyKeys []string
+}
+
+// registryKey separates the labels of vertices from the labels of edges
+type registryKey struct {
+	label string
+	edge  bool
+}
+
+// Registry holds the models used with a driver, they are validated when they are registered
+// a driver opened WithRegistry only queries registered models and takes their labels from the registry
+// a Registry is safe for concurrent use
+type Registry struct {
+	mu      sync.RWMutex
+	byType  map[reflect.Type]ModelInfo
+	byLabel map[registryKey]reflect.Type
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		byType:  make(map[reflect.Type]ModelInfo),
+		byLabel: make(map[registryKey]reflect.Type),
+	}
+}
+
+// Register validates the models and adds them to the registry, a model is a struct value or a pointer to one
+// e.g. registry.Register(User{}, Post{}, Follows{})
+// a model must embed types.Vertex or types.Edge, its tags must be valid and no two fields may be mapped to
+// the same property, two vertex or two edge models with the same label fail with ErrLabelCollision
+// nothing is registered when an error is returned, registering a model again is a no-op
+func (r *Registry) Register(models ...any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	added := make(map[reflect.Type]ModelInfo, len(models))
+	labels := make(map[registryKey]reflect.Type, len(models))
+	var errs []error
+	for _, model := range models {
+		rt := reflect.TypeOf(model)
+		if rt == nil {
+			errs = append(errs, errors.New("model cannot be nil"))
+			continue
+		}
+		for rt.Kind() == reflect.Pointer {
+			rt = rt.Elem()
+		}
+		if _, ok := r.byType[rt]; ok {
+			continue
+		}
+		info, err := newModelInfo(rt)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		key := registryKey{label: info.Label, edge: info.Edge}
+		other, ok := r.byLabel[key]
+		if !ok {
+			other, ok = labels[key]
+		}
+		if ok && other != rt {
+			errs = append(errs, fmt.Errorf("%w: %s of %s is the label of %s", ErrLabelCollision, info.Label, rt, other))
+			continue
+		}
+		added[rt] = info
+		labels[key] = rt
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for rt, info := range added {
+		r.byType[rt] = info
+		r.byLabel[registryKey{label: info.Label, edge: info.Edge}] = rt
+	}
+	return nil
+}
+
+// newModelInfo validates the model type rt and returns its label and property keys
+func newModelInfo(rt reflect.Type) (ModelInfo, error) {
+	isEdge, err := validateModel(rt)
+	if err != nil {
+		return ModelInfo{}, err
+	}
+	keys, err := propertyKeys(rt)
+	if err != nil {
+		return ModelInfo{}, err
+	}
+	info := ModelInfo{Type: rt, Edge: isEdge, PropertyKeys: keys}
+	if isEdge {
+		edge, ok := reflect.New(rt).Interface().(gsmtypes.EdgeType)
+		if !ok {
+			return ModelInfo{}, fmt.Errorf("model %s must implement types.EdgeType", rt.Name())
+		}
+		info.Label = getLabelFromEdge(edge)
+		return info, nil
+	}
+	vertex, ok := reflect.New(rt).Interface().(gsmtypes.VertexType)
+	if !ok {
+		return ModelInfo{}, fmt.Errorf("model %s must implement types.VertexType", rt.Name())
+	}
+	info.Label = getLabelFromVertex(vertex)
+	return info, nil
+}
+
+// Models returns the registered models sorted by label, vertices first
+func (r *Registry) Models() []ModelInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models := make([]ModelInfo, 0, len(r.byType))
+	for _, info := range r.byType {
+		models = append(models, info)
+	}
+	slices.SortFunc(models, func(a ModelInfo, b ModelInfo) int {
+		if a.Edge != b.Edge {
+			if a.Edge {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.Label, b.Label)
+	})
+	return models
+}
+
+// Model returns the registered model of the type of model, a struct value or a pointer to one
+func (r *Registry) Model(model any) (ModelInfo, bool) {
+	rt := reflect.TypeOf(model)
+	if rt == nil {
+		return ModelInfo{}, false
+	}
+	return r.modelOf(rt)
+}
+
+// Vertex returns the registered vertex model with the label
+func (r *Registry) Vertex(label string) (ModelInfo, bool) {
+	return r.lookup(registryKey{label: label})
+}
+
+// Edge returns the registered edge model with the label
+func (r *Registry) Edge(label string) (ModelInfo, bool) {
+	return r.lookup(registryKey{label: label, edge: true})
+}
+
+func (r *Registry) lookup(key registryKey) (ModelInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rt, ok := r.byLabel[key]
+	if !ok {
+		return ModelInfo{}, false
+	}
+	return r.byType[rt], true
+}
+
+func (r *Registry) modelOf(rt reflect.Type) (ModelInfo, bool) {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.byType[rt]
+	return info, ok
+}
+
+// WithRegistry makes the driver only query the models registered in the registry, see Registry
+func WithRegistry(registry *Registry) Option {
+	return optionFunc(func(options *Options) {
+		options.Registry = registry
+	})
+}
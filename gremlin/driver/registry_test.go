@@ -0,0 +1,122 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// testArticleDraft unintentionally shares the label of testArticle
+type testArticleDraft struct {
+	gsmtypes.Vertex
+	Title string `json:"title" gremlin:"title"`
+}
+
+func (testArticleDraft) Label() string {
+	return "test_article"
+}
+
+type testDuplicateTags struct {
+	gsmtypes.Vertex
+	testAudit
+	Name   string `json:"name"   gremlin:"name"`
+	Author string `json:"author" gremlin:"editor"`
+}
+
+func TestRegistryBuild(t *testing.T) {
+	t.Parallel()
+	t.Run(
+		"TestRegister", func(t *testing.T) {
+			t.Parallel()
+			registry := NewRegistry()
+			if err := registry.Register(testArticle{}, &testCustomer{}, testEdge{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := registry.Register(testArticle{}); err != nil {
+				t.Errorf("Expected registering a model again to be a no-op, got %v", err)
+			}
+			labels := make([]string, 0)
+			for _, info := range registry.Models() {
+				labels = append(labels, info.Label)
+			}
+			if expected := []string{"test_article", "test_customer", "test_edge"}; !slices.Equal(labels, expected) {
+				t.Errorf("Expected %v, got %v", expected, labels)
+			}
+			info, ok := registry.Vertex("test_article")
+			if !ok || info.Type != reflect.TypeFor[testArticle]() || info.Edge {
+				t.Fatalf("Expected the testArticle vertex model, got %+v", info)
+			}
+			for _, key := range []string{"address.city", "body", "editor", "meta.*", "title"} {
+				if !slices.Contains(info.PropertyKeys, key) {
+					t.Errorf("Expected property key %s, got %v", key, info.PropertyKeys)
+				}
+			}
+			if _, ok = registry.Edge("test_edge"); !ok {
+				t.Error("Expected the testEdge edge model")
+			}
+			if _, ok = registry.Vertex("test_edge"); ok {
+				t.Error("Expected vertex and edge labels to be looked up separately")
+			}
+			if _, ok = registry.Model(&testCustomer{}); !ok {
+				t.Error("Expected a model to be looked up by a pointer value")
+			}
+		},
+	)
+	t.Run(
+		"TestRegisterErrors", func(t *testing.T) {
+			t.Parallel()
+			registry := NewRegistry()
+			if err := registry.Register(testArticle{}); err != nil {
+				t.Fatal(err)
+			}
+			err := registry.Register(testCustomer{}, testArticleDraft{})
+			if !errors.Is(err, ErrLabelCollision) {
+				t.Errorf("Expected ErrLabelCollision, got %v", err)
+			}
+			if _, ok := registry.Model(testCustomer{}); ok {
+				t.Error("Expected nothing to be registered when an error is returned")
+			}
+			tests := map[string]struct {
+				model    any
+				contains string
+			}{
+				"TestNil":           {nil, "cannot be nil"},
+				"TestNotAModel":     {testUnregistered{}, "must embed"},
+				"TestInvalidTags":   {testInvalidTags{}, "field Count"},
+				"TestDuplicateTags": {testDuplicateTags{}, "Editor and Author"},
+			}
+			for name, tt := range tests {
+				if err = registry.Register(tt.model); err == nil || !strings.Contains(err.Error(), tt.contains) {
+					t.Errorf("%s: expected an error containing %q, got %v", name, tt.contains, err)
+				}
+			}
+		},
+	)
+	t.Run(
+		"TestQueryUsesRegistry", func(t *testing.T) {
+			t.Parallel()
+			registry := NewRegistry()
+			if err := registry.Register(testArticle{}); err != nil {
+				t.Fatal(err)
+			}
+			db := newOfflineDriver()
+			db.registry = registry
+			if query := Model[testArticle](db); query.label != "test_article" || len(query.errs) > 0 {
+				t.Errorf("Expected the registered label, got %s %v", query.label, query.errs)
+			}
+			var validationErr *ValidationError
+			_, err := Model[testCustomer](db).Count()
+			if !errors.As(err, &validationErr) || !errors.Is(err, ErrModelNotRegistered) {
+				t.Errorf("Expected ErrModelNotRegistered, got %v", err)
+			}
+			if _, err = Model[testCustomer](db).ID(1); !errors.As(err, &validationErr) ||
+				!errors.Is(err, ErrModelNotRegistered) {
+				t.Errorf("Expected ErrModelNotRegistered from ID, got %v", err)
+			}
+		},
+	)
+}
@@ -0,0 +1,315 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/gobeam/stringy"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// fieldEncoding is how a field mapped to a property is written and loaded
+type fieldEncoding int
+
+const (
+	// encodePlain fields hold strings, booleans or numbers which are written as they are
+	encodePlain fieldEncoding = iota
+	// encodeConverted fields are converted by setPropertyValue and assignValue, e.g. pointers, slices and time.Time
+	encodeConverted
+	// encodeJSON fields are stored as a single JSON encoded string
+	encodeJSON
+	// encodeNested fields are flattened into prefixed properties, see isNestedStruct
+	encodeNested
+	// encodeMap fields are stored as a property per key, see isStringMap
+	encodeMap
+)
+
+// schemaField is a field of a model mapped to a property, a sub traversal or a preloaded edge
+type schemaField struct {
+	// index is the path to the field through the embedded structs of the model, see reflect.Value.FieldByIndex
+	index []int
+	// name is the name of the Go field
+	name         string
+	tag          gremlinTag
+	encoding     fieldEncoding
+	subTraversal string
+}
+
+// typeSchema is the reflection metadata of a model type, it is computed once per type, see schemaOf
+type typeSchema struct {
+	rt reflect.Type
+	// label is the snake case name of the type used when Label returns an empty string
+	label string
+	// properties holds the fields mapped to a property or a sub traversal in declaration order
+	properties []schemaField
+	// edges holds the gremlinEdge fields which preloaded vertices are loaded into
+	edges []schemaField
+	// embedded holds the types of the anonymous fields declared directly in the type
+	embedded map[reflect.Type]bool
+	// tags holds the parsed gremlin tags of the fields declared directly in the type by field index
+	// fields which are not mapped to a property have an empty name, see parseStructTags
+	tags []gremlinTag
+	// tagsErr joins the errors of the invalid gremlin tags in tags
+	tagsErr error
+	// fieldNames is filled on first use by gremlinFieldNames as a nested struct may nest the type itself
+	fieldNames     map[string]struct{}
+	fieldNamesOnce sync.Once
+	// multiValued caches multiValuedProperties per DatabaseDriver
+	multiValued sync.Map
+	// err joins the errors of invalid gremlin tags of the type and its embedded structs
+	err error
+}
+
+// multiValuedEntry is a cached result of multiValuedProperties
+type multiValuedEntry struct {
+	cardinalities map[string]any
+	err           error
+}
+
+// schemaCache holds the schema of every type seen so far keyed by reflect.Type
+var schemaCache sync.Map
+
+// schemaOf returns the schema of the struct type rt, pointers are dereferenced
+func schemaOf(rt reflect.Type) *typeSchema {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if cached, ok := schemaCache.Load(rt); ok {
+		if schema, isSchema := cached.(*typeSchema); isSchema {
+			return schema
+		}
+	}
+	schema := newTypeSchema(rt)
+	// a schema computed concurrently for the same type is equal so whichever is stored first is kept
+	cached, _ := schemaCache.LoadOrStore(rt, schema)
+	if stored, isSchema := cached.(*typeSchema); isSchema {
+		return stored
+	}
+	return schema
+}
+
+func newTypeSchema(rt reflect.Type) *typeSchema {
+	schema := &typeSchema{
+		rt:         rt,
+		label:      stringy.New(rt.Name()).SnakeCase().ToLower(),
+		properties: make([]schemaField, 0),
+		edges:      make([]schemaField, 0),
+		embedded:   make(map[reflect.Type]bool),
+		fieldNames: make(map[string]struct{}),
+	}
+	if rt.Kind() != reflect.Struct {
+		return schema
+	}
+	for i := range rt.NumField() {
+		if rt.Field(i).Anonymous {
+			schema.embedded[rt.Field(i).Type] = true
+		}
+	}
+	schema.tags, schema.tagsErr = parseStructTags(rt)
+	var errs []error
+	schema.addFields(rt, nil, &errs)
+	schema.err = errors.Join(errs...)
+	return schema
+}
+
+// addFields adds the fields of rt, a struct embedded in the model at index, and of the structs embedded in it
+func (s *typeSchema) addFields(rt reflect.Type, index []int, errs *[]error) {
+	// the tags of an embedded struct are taken from its own schema
+	owner := s
+	if rt != s.rt {
+		owner = schemaOf(rt)
+	}
+	tags := owner.tags
+	if owner.tagsErr != nil {
+		*errs = append(*errs, owner.tagsErr)
+	}
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		fieldIndex := append(append(make([]int, 0, len(index)+1), index...), i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			s.addFields(field.Type, fieldIndex, errs)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if field.Tag.Get(gsmtypes.GremlinEdgeTag) != "" {
+			s.edges = append(s.edges, schemaField{index: fieldIndex, name: field.Name})
+			continue
+		}
+		subTraversal := field.Tag.Get(gsmtypes.GremlinSubTraversalTag)
+		if subTraversal == "-" {
+			subTraversal = ""
+		}
+		if tags[i].name == "" && subTraversal == "" {
+			continue
+		}
+		s.properties = append(s.properties, schemaField{
+			index:        fieldIndex,
+			name:         field.Name,
+			tag:          tags[i],
+			encoding:     encodingOf(field.Type, tags[i]),
+			subTraversal: subTraversal,
+		})
+	}
+}
+
+// encodingOf returns how a field of type rt with the tag is written and loaded
+func encodingOf(rt reflect.Type, tag gremlinTag) fieldEncoding {
+	switch {
+	case tag.json:
+		return encodeJSON
+	case isNestedStruct(rt):
+		return encodeNested
+	case isStringMap(rt):
+		return encodeMap
+	}
+	switch rt.Kind() { //nolint: exhaustive // every other kind is converted
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !convertsItself(rt) {
+			return encodePlain
+		}
+	}
+	return encodeConverted
+}
+
+// property returns the field declared directly in the model which is mapped to the property name
+func (s *typeSchema) property(name string) (*schemaField, bool) {
+	for i := range s.properties {
+		if field := &s.properties[i]; len(field.index) == 1 && field.tag.name == name {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// cardinalities returns multiValuedProperties of the type for the database driver computing them once
+func (s *typeSchema) cardinalities(dbDriver DatabaseDriver) (map[string]any, error) {
+	if cached, ok := s.multiValued.Load(dbDriver); ok {
+		if entry, isEntry := cached.(multiValuedEntry); isEntry {
+			return entry.cardinalities, entry.err
+		}
+	}
+	cardinalities, err := multiValuedProperties(dbDriver, s.rt)
+	s.multiValued.Store(dbDriver, multiValuedEntry{cardinalities: cardinalities, err: err})
+	return cardinalities, err
+}
+
+// Register computes the schema of the model type T up front and validates it
+// so invalid tags or a missing types.Vertex or types.Edge fail at startup instead of on the first write or query
+// models which are not registered are handled the same way, their schema is computed when they are first used
+func Register[T any]() error {
+	_, err := validateModel(reflect.TypeFor[T]())
+	return err
+}
+
+// validateModel checks that rt is a struct embedding types.Vertex or types.Edge with valid tags
+// no two fields may be mapped to the same property and the cascading deletes of a vertex must resolve
+// isEdge reports whether rt is an edge model
+func validateModel(rt reflect.Type) (bool, error) {
+	if rt.Kind() != reflect.Struct {
+		return false, fmt.Errorf("model %s must be a struct", rt)
+	}
+	schema := schemaOf(rt)
+	isVertex := schema.embedded[reflect.TypeFor[gsmtypes.Vertex]()]
+	isEdge := schema.embedded[reflect.TypeFor[gsmtypes.Edge]()]
+	if !isVertex && !isEdge {
+		return false, fmt.Errorf("model %s must embed types.Vertex or types.Edge", rt.Name())
+	}
+	if err := validateSchema(rt, map[reflect.Type]bool{}); err != nil {
+		return isEdge, err
+	}
+	if _, err := propertyKeys(rt); err != nil {
+		return isEdge, err
+	}
+	if isVertex {
+		if _, err := cascadeRules(rt); err != nil {
+			return isEdge, err
+		}
+	}
+	return isEdge, nil
+}
+
+// propertyKeys returns the sorted names of the properties written for rt
+// nested structs are expanded with their prefix and map fields have a wildcard key, e.g. meta.*
+// an error is returned when two fields are mapped to the same property
+func propertyKeys(rt reflect.Type) ([]string, error) {
+	fields := make(map[string]string)
+	if err := addPropertyKeys(rt, "", "", fields, map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(fields)), nil
+}
+
+// addPropertyKeys adds the property names of rt to fields keyed by name with the path of the Go field
+func addPropertyKeys(
+	rt reflect.Type,
+	prefix string,
+	path string,
+	fields map[string]string,
+	visiting map[reflect.Type]bool,
+) error {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	// a struct nesting itself through a pointer is only expanded once
+	if visiting[rt] {
+		return nil
+	}
+	visiting[rt] = true
+	defer delete(visiting, rt)
+	for _, field := range schemaOf(rt).properties {
+		if field.tag.name == "" {
+			continue
+		}
+		name := prefix + field.tag.name
+		fieldPath := path + field.name
+		switch field.encoding { //nolint: exhaustive // every other encoding is a single property
+		case encodeNested:
+			err := addPropertyKeys(
+				rt.FieldByIndex(field.index).Type, name+nestedFieldSeparator, fieldPath+".", fields, visiting,
+			)
+			if err != nil {
+				return err
+			}
+			continue
+		case encodeMap:
+			name += nestedFieldSeparator + mapKeyWildcard
+		}
+		if other, ok := fields[name]; ok {
+			return fmt.Errorf("fields %s and %s of %s are both mapped to property %s", other, fieldPath, rt.Name(), name)
+		}
+		fields[name] = fieldPath
+	}
+	return nil
+}
+
+// validateSchema returns the errors of the gremlin and gremlinEdge tags of rt and of the structs nested in it
+func validateSchema(rt reflect.Type, visited map[reflect.Type]bool) error {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if visited[rt] {
+		return nil
+	}
+	visited[rt] = true
+	schema := schemaOf(rt)
+	errs := []error{schema.err}
+	for _, edge := range schema.edges {
+		if _, err := parseEdgeTag(rt.FieldByIndex(edge.index).Tag.Get(gsmtypes.GremlinEdgeTag)); err != nil {
+			errs = append(errs, fmt.Errorf("field %s of %s: %w", edge.name, rt.Name(), err))
+		}
+	}
+	for _, field := range schema.properties {
+		if field.encoding == encodeNested {
+			errs = append(errs, validateSchema(rt.FieldByIndex(field.index).Type, visited))
+		}
+	}
+	return errors.Join(errs...)
+}
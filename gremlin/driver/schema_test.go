@@ -0,0 +1,171 @@
+package driver
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testAudit struct {
+	Editor string `json:"editor" gremlin:"editor"`
+}
+
+type testArticle struct {
+	gsmtypes.Vertex
+	testAudit
+	Title    string         `json:"title"    gremlin:"title"`
+	Level    testLevel      `json:"level"    gremlin:"level"`
+	Released time.Time      `json:"released" gremlin:"released"`
+	Address  testAddress    `json:"address"  gremlin:"address"`
+	Meta     map[string]int `json:"meta"     gremlin:"meta"`
+	Body     string         `json:"body"     gremlin:"body,json"`
+	Comments int            `json:"comments" gremlinSubTraversal:"comments"`
+	Parts    []*testPart    `json:"parts"    gremlinEdge:"has_part"`
+	internal string
+}
+
+// testLevel is loaded through assignValue as gremlin returns the underlying int64
+type testLevel int
+
+type testUnregistered struct {
+	Name string `json:"name" gremlin:"name"`
+}
+
+type testInvalidEdge struct {
+	gsmtypes.Vertex
+	Parts []*testPart `json:"parts" gremlinEdge:"has_part,sideways"`
+}
+
+type testInvalidNested struct {
+	gsmtypes.Vertex
+	Inner testInvalidInner `json:"inner" gremlin:"inner"`
+}
+
+type testInvalidInner struct {
+	Count int `json:"count" gremlin:"count,default=many"`
+}
+
+// testCategory nests itself, its schema must not be computed while it is being built
+type testCategory struct {
+	gsmtypes.Vertex
+	Name   string        `json:"name"   gremlin:"name"`
+	Parent *testCategory `json:"parent" gremlin:"parent"`
+}
+
+func TestSchemaBuild(t *testing.T) {
+	t.Parallel()
+	t.Run(
+		"TestSchemaIsCached", func(t *testing.T) {
+			t.Parallel()
+			if schemaOf(reflect.TypeFor[testArticle]()) != schemaOf(reflect.TypeFor[*testArticle]()) {
+				t.Error("Expected the schema of a type to be computed once and shared with its pointer type")
+			}
+		},
+	)
+	t.Run(
+		"TestSchemaFields", func(t *testing.T) {
+			t.Parallel()
+			schema := schemaOf(reflect.TypeFor[testArticle]())
+			if schema.err != nil {
+				t.Fatal(schema.err)
+			}
+			if schema.label != "test_article" {
+				t.Errorf("Expected label test_article, got %s", schema.label)
+			}
+			expected := map[string]fieldEncoding{
+				"Editor":   encodePlain,
+				"Title":    encodePlain,
+				"Level":    encodePlain,
+				"Released": encodeConverted,
+				"Address":  encodeNested,
+				"Meta":     encodeMap,
+				"Body":     encodeJSON,
+				"Comments": encodePlain,
+			}
+			names := make([]string, 0, len(schema.properties))
+			for _, field := range schema.properties {
+				names = append(names, field.name)
+				if encoding, ok := expected[field.name]; ok && encoding != field.encoding {
+					t.Errorf("Expected %s to be encoded as %d, got %d", field.name, encoding, field.encoding)
+				}
+			}
+			if slices.Contains(names, "internal") {
+				t.Error("Expected unexported fields to be left out")
+			}
+			for name := range expected {
+				if !slices.Contains(names, name) {
+					t.Errorf("Expected a field for %s, got %v", name, names)
+				}
+			}
+			editor := schema.properties[slices.Index(names, "Editor")]
+			if !slices.Equal(editor.index, []int{1, 0}) {
+				t.Errorf("Expected the index path of an embedded field, got %v", editor.index)
+			}
+			if len(schema.edges) != 1 || schema.edges[0].name != "Parts" {
+				t.Errorf("Expected the Parts edge, got %+v", schema.edges)
+			}
+			if _, ok := schema.property("editor"); ok {
+				t.Error("Expected only fields declared directly in the model to be looked up")
+			}
+			if _, ok := gremlinFieldNames(reflect.TypeFor[testArticle]())["address.city"]; !ok {
+				t.Error("Expected the nested property names to be cached")
+			}
+			fieldNames := gremlinFieldNames(reflect.TypeFor[testCategory]())
+			if _, ok := fieldNames["parent"]; !ok {
+				t.Errorf("Expected the properties of a struct nesting itself, got %v", fieldNames)
+			}
+		},
+	)
+	t.Run(
+		"TestSchemaRoundTrip", func(t *testing.T) {
+			t.Parallel()
+			article := testArticle{Title: "a", Level: 2, Address: testAddress{City: "x"}}
+			article.Editor = "b"
+			_, properties, err := structToMap(&article)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if properties["editor"] != "b" || properties["level"] != testLevel(2) || properties["address.city"] != "x" {
+				t.Errorf("Expected the properties of the article, got %v", properties)
+			}
+			var loaded testArticle
+			if err = recursivelyUnloadIntoStruct(&loaded, properties); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Editor != "b" || loaded.Title != "a" || loaded.Level != 2 || loaded.Address.City != "x" {
+				t.Errorf("Expected %+v, got %+v", article, loaded)
+			}
+		},
+	)
+	t.Run(
+		"TestRegister", func(t *testing.T) {
+			t.Parallel()
+			if err := Register[testArticle](); err != nil {
+				t.Errorf("Expected a valid model, got %v", err)
+			}
+			if err := Register[testFolder](); err != nil {
+				t.Errorf("Expected a valid model with cascading deletes, got %v", err)
+			}
+			tests := map[string]struct {
+				register func() error
+				contains string
+			}{
+				"TestNotAStruct":    {Register[string], "must be a struct"},
+				"TestNotAModel":     {Register[testUnregistered], "must embed"},
+				"TestInvalidTags":   {Register[testInvalidTags], "field Count"},
+				"TestInvalidEdge":   {Register[testInvalidEdge], "field Parts"},
+				"TestInvalidNested": {Register[testInvalidNested], "field Count of testInvalidInner"},
+			}
+			for name, tt := range tests {
+				err := tt.register()
+				if err == nil || !strings.Contains(err.Error(), tt.contains) {
+					t.Errorf("%s: expected an error containing %q, got %v", name, tt.contains, err)
+				}
+			}
+		},
+	)
+}
@@ -0,0 +1,76 @@
+package driver
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// embedsSoftDelete reports whether the struct type embeds gsmtypes.SoftDelete
+func embedsSoftDelete(rt reflect.Type) bool {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return false
+	}
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.Anonymous {
+			continue
+		}
+		if field.Type == reflect.TypeFor[gsmtypes.SoftDelete]() || embedsSoftDelete(field.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// softDeleteVertices marks the vertices as deleted at now
+func softDeleteVertices(now time.Time) func(*gremlingo.GraphTraversal) *gremlingo.GraphTraversal {
+	return func(query *gremlingo.GraphTraversal) *gremlingo.GraphTraversal {
+		return query.
+			Property(cardinality.Single, gsmtypes.DeletedAt, now).
+			Property(cardinality.Single, gsmtypes.LastModified, now)
+	}
+}
+
+// Unscoped includes soft deleted vertices in the query and makes Delete drop them
+func (q *Query[T]) Unscoped() *Query[T] {
+	q.writeDebugString(".Unscoped()")
+	q.unscoped = true
+	return q
+}
+
+// HardDelete drops all matching results and the vertices they own even when the model embeds SoftDelete
+// soft deleted vertices are only matched when the query is Unscoped
+func (q *Query[T]) HardDelete() error {
+	q.writeDebugString(".Drop().Iterate()")
+	rules, err := cascadeRules(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+	return q.delete(dropVertices(rules, q.unscoped))
+}
+
+// Restore removes deleted_at from all matching soft deleted vertices, soft deleted vertices are always matched
+func (q *Query[T]) Restore() error {
+	if !q.softDelete {
+		return fmt.Errorf("%s does not embed SoftDelete", reflect.TypeFor[T]().Name())
+	}
+	q.writeDebugString(".Has(deleted_at).Properties(deleted_at).Drop().Iterate()")
+	// the soft deleted vertices are matched by a copy so the query itself stays scoped
+	unscoped := *q
+	unscoped.unscoped = true
+	query, err := unscoped.build()
+	if err != nil {
+		return err
+	}
+	query = query.Has(gsmtypes.DeletedAt).
+		SideEffect(anonymousTraversal.Properties(gsmtypes.DeletedAt).Drop()).
+		Property(cardinality.Single, gsmtypes.LastModified, time.Now().UTC())
+	return awaitIterate(q.db, query.Iterate)
+}
@@ -0,0 +1,199 @@
+package driver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testArchived struct {
+	gsmtypes.Vertex
+	gsmtypes.SoftDelete
+	Name string `json:"name" gremlin:"name"`
+}
+
+// testShelf owns archived items, soft deleted items are neither preloaded nor owned by a scoped delete
+type testShelf struct {
+	gsmtypes.Vertex
+	Items []testArchived `json:"items" gremlinEdge:"test_edge,cascade"`
+}
+
+// testTombstone has its own deleted_at property without embedding SoftDelete
+type testTombstone struct {
+	gsmtypes.Vertex
+	DeletedAt string `json:"deleted_at" gremlin:"deleted_at"`
+}
+
+func TestSoftDeleteBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	tests := []struct {
+		testName string
+		query    *Query[testArchived]
+		expected string
+	}{
+		{
+			testName: "TestScoped",
+			query:    Model[testArchived](db).Where("name", comparator.EQ, "a"),
+			expected: "g.V().hasLabel('test_archived').hasNot('deleted_at').has('name','a')",
+		},
+		{
+			testName: "TestUnscoped",
+			query:    Model[testArchived](db).Unscoped().Where("deleted_at", comparator.EXISTS, nil),
+			expected: "g.V().hasLabel('test_archived').has('deleted_at')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.testName, func(t *testing.T) {
+				t.Parallel()
+				if got := translate(t, tt.query.BuildQuery()); got != tt.expected {
+					t.Errorf("Expected %s, got %s", tt.expected, got)
+				}
+			},
+		)
+	}
+	t.Run(
+		"TestModelsWithoutSoftDelete", func(t *testing.T) {
+			t.Parallel()
+			if got := translate(t, Model[testVertex](db).BuildQuery()); got != "g.V().hasLabel('test_vertex')" {
+				t.Errorf("Expected no deleted_at filter, got %s", got)
+			}
+			if !embedsSoftDelete(reflect.TypeFor[testArchived]()) || embedsSoftDelete(reflect.TypeFor[testVertex]()) {
+				t.Error("Expected only testArchived to embed SoftDelete")
+			}
+			if err := Model[testVertex](db).Restore(); err == nil {
+				t.Error("Expected error restoring a model without SoftDelete")
+			}
+		},
+	)
+	t.Run(
+		"TestDeletedAtIsNotWritten", func(t *testing.T) {
+			t.Parallel()
+			archived := testArchived{Name: "a"}
+			archived.DeletedAt = time.Now()
+			_, properties, err := structToMap(&archived)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := properties[gsmtypes.DeletedAt]; ok || properties["name"] != "a" {
+				t.Errorf("Expected name without deleted_at, got %v", properties)
+			}
+		},
+	)
+	t.Run(
+		"TestDeletedAtOfOtherModels", func(t *testing.T) {
+			t.Parallel()
+			_, properties, err := structToMap(&testTombstone{DeletedAt: "never"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if properties[gsmtypes.DeletedAt] != "never" {
+				t.Errorf("Expected deleted_at of a model without SoftDelete to be written, got %v", properties)
+			}
+		},
+	)
+	t.Run(
+		"TestSoftDeletedNeighbours", func(t *testing.T) {
+			t.Parallel()
+			rules, err := cascadeRules(reflect.TypeFor[testShelf]())
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := "g.V(1).union(identity(),repeat(union(hasLabel('test_shelf').out('test_edge')." +
+				"hasLabel('test_archived').hasNot('deleted_at')).simplePath()).emit()).dedup().fold().unfold().drop()"
+			if got := translate(t, dropVertices(rules, false)(db.g.V(1))); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+			if got := translate(t, dropVertices(rules, true)(db.g.V(1))); strings.Contains(got, "hasNot(") {
+				t.Errorf("Expected an unscoped delete to own soft deleted vertices, got %s", got)
+			}
+			subTraversals, err := preloadSubTraversals(reflect.TypeFor[testShelf](), newPreloadTree([]string{"Items"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := translate(t, subTraversals["Items"]); !strings.HasPrefix(
+				got, "g.out('test_edge').hasLabel('test_archived').hasNot('deleted_at').",
+			) {
+				t.Errorf("Expected soft deleted items not to be preloaded, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestRestoreKeepsQueryScoped", func(t *testing.T) {
+			t.Parallel()
+			query := Model[testArchived](db)
+			// the offline driver cannot write, the query is checked after the restore was built
+			_ = query.Restore()
+			if got := translate(t, query.BuildQuery()); !strings.Contains(got, "hasNot('deleted_at')") {
+				t.Errorf("Expected the query to stay scoped after Restore, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestSoftDeleteStep", func(t *testing.T) {
+			t.Parallel()
+			got := translate(t, softDeleteVertices(time.Now().UTC())(db.g.V(1)))
+			if !strings.HasPrefix(got, "g.V(1).property(single,'deleted_at',") || strings.Contains(got, "drop()") {
+				t.Errorf("Expected deleted_at to be set, got %s", got)
+			}
+		},
+	)
+}
+
+func TestSoftDelete(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+
+	archived := testArchived{Name: "a"}
+	if err = Create(db, &archived); err != nil {
+		t.Fatal(err)
+	}
+	count := func(query *Query[testArchived]) int {
+		t.Helper()
+		n, countErr := query.Where("name", comparator.EQ, "a").Count()
+		if countErr != nil {
+			t.Fatal(countErr)
+		}
+		return n
+	}
+
+	if err = Model[testArchived](db).Where("name", comparator.EQ, "a").Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(Model[testArchived](db)); n != 0 {
+		t.Errorf("Expected soft deleted vertex to be skipped, got %d", n)
+	}
+	if _, err = Model[testArchived](db).ID(archived.ID); err == nil {
+		t.Error("Expected soft deleted vertex not to be found by id")
+	}
+	deleted, err := Model[testArchived](db).Unscoped().ID(archived.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deleted.IsDeleted() {
+		t.Error("Expected deleted_at to be set")
+	}
+
+	if err = Model[testArchived](db).Where("name", comparator.EQ, "a").Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(Model[testArchived](db)); n != 1 {
+		t.Errorf("Expected restored vertex, got %d", n)
+	}
+
+	if err = Model[testArchived](db).Where("name", comparator.EQ, "a").HardDelete(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(Model[testArchived](db).Unscoped()); n != 0 {
+		t.Errorf("Expected vertex to be dropped, got %d", n)
+	}
+}
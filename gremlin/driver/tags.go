@@ -0,0 +1,216 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+const (
+	// uniqueTagOption marks a field as a natural key, see Upsert
+	uniqueTagOption = "unique"
+	// versionTagOption marks an integer field as the optimistic locking counter, see ErrStaleObject
+	versionTagOption = "version"
+	// jsonTagOption stores the field as a single JSON encoded string property instead of flattening it
+	jsonTagOption = "json"
+	// singleTagOption, listTagOption and setTagOption choose the cardinality of a slice field, see fieldCardinality
+	singleTagOption = "single"
+	listTagOption   = "list"
+	setTagOption    = "set"
+	// omitEmptyTagOption skips writing the field while it holds its zero value
+	omitEmptyTagOption = "omitempty"
+	// readOnlyTagOption loads the field but never writes it, writeOnlyTagOption writes the field but never loads it
+	readOnlyTagOption  = "readonly"
+	writeOnlyTagOption = "writeonly"
+	// requiredTagOption rejects writing the field while it holds its zero value, see ErrRequiredField
+	requiredTagOption = "required"
+	// defaultTagOption sets the field to the value following it when it is written holding its zero value
+	// e.g. `gremlin:"status,default=active"`
+	defaultTagOption = "default="
+)
+
+// gremlinTag is the parsed gremlin struct tag of a field, e.g. `gremlin:"email,unique"`
+type gremlinTag struct {
+	name    string
+	unique  bool
+	version bool
+	json    bool
+	// cardinality is the single, list or set option, empty when the field uses the default cardinality
+	cardinality string
+	omitEmpty   bool
+	readOnly    bool
+	writeOnly   bool
+	required    bool
+	// defaultValue holds the default option converted to the type of the field, it is invalid without one
+	defaultValue reflect.Value
+}
+
+// parseGremlinTag parses the gremlin tag of the field, ok is false when the field is not mapped to a property
+// the tag is returned with the options it could parse together with an error for every invalid option
+func parseGremlinTag(field reflect.StructField) (gremlinTag, bool, error) {
+	name, options, _ := strings.Cut(field.Tag.Get(gsmtypes.GremlinTag), ",")
+	if name == "" || name == "-" {
+		return gremlinTag{}, false, nil
+	}
+	tag := gremlinTag{name: name}
+	var errs []error
+	for option := range strings.SplitSeq(options, ",") {
+		option = strings.TrimSpace(option)
+		switch option {
+		case "":
+		case uniqueTagOption:
+			tag.unique = true
+		case versionTagOption:
+			tag.version = true
+		case jsonTagOption:
+			tag.json = true
+		case singleTagOption, listTagOption, setTagOption:
+			tag.cardinality = option
+		case omitEmptyTagOption:
+			tag.omitEmpty = true
+		case readOnlyTagOption:
+			tag.readOnly = true
+		case writeOnlyTagOption:
+			tag.writeOnly = true
+		case requiredTagOption:
+			tag.required = true
+		default:
+			text, ok := strings.CutPrefix(option, defaultTagOption)
+			if !ok {
+				errs = append(errs, fmt.Errorf("gremlin tag %s has unknown option %s", name, option))
+				continue
+			}
+			value, err := parseDefaultValue(field.Type, text)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("gremlin tag %s has invalid default %q: %w", name, text, err))
+				continue
+			}
+			tag.defaultValue = value
+		}
+	}
+	if tag.readOnly && tag.writeOnly {
+		errs = append(errs, fmt.Errorf("gremlin tag %s cannot be both %s and %s", name, readOnlyTagOption,
+			writeOnlyTagOption))
+	}
+	return tag, true, errors.Join(errs...)
+}
+
+// parseDefaultValue converts the text of a default option to a value of type rt
+// strings, booleans and numbers are parsed directly, other types are loaded like a string property
+func parseDefaultValue(rt reflect.Type, text string) (reflect.Value, error) {
+	switch rt.Kind() { //nolint: exhaustive // only kinds holding shared references are rejected
+	case reflect.Pointer:
+		elem, err := parseDefaultValue(rt.Elem(), text)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		value := reflect.New(rt.Elem())
+		value.Elem().Set(elem)
+		return value, nil
+	case reflect.Slice, reflect.Map, reflect.Interface:
+		return reflect.Value{}, fmt.Errorf("%s fields cannot have a default", rt.Kind())
+	}
+	value := reflect.New(rt).Elem()
+	var err error
+	switch rt.Kind() { //nolint: exhaustive // every other kind is loaded like a string property
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Bool:
+		var parsed bool
+		parsed, err = strconv.ParseBool(text)
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var parsed int64
+		parsed, err = strconv.ParseInt(text, 10, rt.Bits())
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var parsed uint64
+		parsed, err = strconv.ParseUint(text, 10, rt.Bits())
+		value.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		var parsed float64
+		parsed, err = strconv.ParseFloat(text, rt.Bits())
+		value.SetFloat(parsed)
+	default:
+		err = assignValue(value, text)
+	}
+	return value, err
+}
+
+// defaultFieldValue sets the zero field to the default of its tag and returns it
+// the default is only returned when the field cannot be set, pointers are copied so values never share it
+func defaultFieldValue(field reflect.Value, tag gremlinTag) reflect.Value {
+	if !tag.defaultValue.IsValid() {
+		return field
+	}
+	value := tag.defaultValue
+	if value.Kind() == reflect.Pointer {
+		value = reflect.New(value.Type().Elem())
+		value.Elem().Set(tag.defaultValue.Elem())
+	}
+	if !field.CanSet() {
+		return value
+	}
+	field.Set(value)
+	return field
+}
+
+// parseStructTags parses the gremlin tags of the fields of the struct type rt by field index
+// fields which are not mapped to a property have an empty name, the result is kept in the schema of rt
+func parseStructTags(rt reflect.Type) ([]gremlinTag, error) {
+	tags := make([]gremlinTag, rt.NumField())
+	var errs []error
+	for i := range rt.NumField() {
+		tag, _, err := parseGremlinTag(rt.Field(i))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s of %s: %w", rt.Field(i).Name, rt.Name(), err))
+		}
+		tags[i] = tag
+	}
+	return tags, errors.Join(errs...)
+}
+
+// uniqueFieldNames returns the property names of the fields tagged unique in declaration order
+func uniqueFieldNames(rt reflect.Type) []string {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	names := make([]string, 0)
+	if rt.Kind() != reflect.Struct {
+		return names
+	}
+	tags := schemaOf(rt).tags
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if field.Anonymous {
+			names = append(names, uniqueFieldNames(field.Type)...)
+			continue
+		}
+		if tags[i].unique {
+			names = append(names, tags[i].name)
+		}
+	}
+	return names
+}
+
+// versionField returns the field of rv tagged version, ok is false when the struct has none
+func versionField(rv reflect.Value) (reflect.Value, gremlinTag, bool) {
+	tags := schemaOf(rv.Type()).tags
+	for i := range rv.NumField() {
+		field := rv.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if value, tag, ok := versionField(rv.Field(i)); ok {
+				return value, tag, true
+			}
+			continue
+		}
+		if tags[i].version {
+			return rv.Field(i), tags[i], true
+		}
+	}
+	return reflect.Value{}, gremlinTag{}, false
+}
@@ -0,0 +1,186 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testProfile struct {
+	gsmtypes.Vertex
+	Name     string `json:"name"     gremlin:"name,required"`
+	Status   string `json:"status"   gremlin:"status,default=active"`
+	Score    *int   `json:"score"    gremlin:"score,default=10"`
+	Nickname string `json:"nickname" gremlin:"nickname,omitempty"`
+	Views    int    `json:"views"    gremlin:"views,readonly"`
+	Secret   string `json:"secret"   gremlin:"secret,writeonly"`
+}
+
+type testInvalidTags struct {
+	gsmtypes.Vertex
+	Count  int      `json:"count"  gremlin:"count,default=many"`
+	Mode   string   `json:"mode"   gremlin:"mode,readonly,writeonly"`
+	Kind   string   `json:"kind"   gremlin:"kind,uniqe"`
+	Labels []string `json:"labels" gremlin:"labels,default=a"`
+}
+
+func TestTagsValidation(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestWriteRules", func(t *testing.T) {
+			t.Parallel()
+			profile := testProfile{Name: "a", Views: 3, Secret: "s"}
+			_, properties, err := structToMap(&profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := map[string]any{"name": "a", "status": "active", "score": 10, "secret": "s"}
+			for key, value := range expected {
+				if properties[key] != value {
+					t.Errorf("Expected %s to be %v, got %v", key, value, properties[key])
+				}
+			}
+			for _, key := range []string{"nickname", "views"} {
+				if _, ok := properties[key]; ok {
+					t.Errorf("Expected no %s property, got %v", key, properties[key])
+				}
+			}
+			if profile.Status != "active" || profile.Score == nil || *profile.Score != 10 {
+				t.Errorf("Expected the defaults to be set on the value, got %+v", profile)
+			}
+			other := testProfile{Name: "b"}
+			if _, _, err = structToMap(&other); err != nil {
+				t.Fatal(err)
+			}
+			if other.Score == profile.Score {
+				t.Error("Expected every value to get its own default pointer")
+			}
+		},
+	)
+	t.Run(
+		"TestRequired", func(t *testing.T) {
+			t.Parallel()
+			if _, _, err := structToMap(&testProfile{}); !errors.Is(err, ErrRequiredField) {
+				t.Errorf("Expected ErrRequiredField, got %v", err)
+			}
+			err := Model[testProfile](db).Where("name", comparator.EQ, "a").Update("name", "")
+			if !errors.Is(err, ErrRequiredField) {
+				t.Errorf("Expected ErrRequiredField, got %v", err)
+			}
+			var validationErr *ValidationError
+			query := Model[testProfile](db).Where("name", comparator.EQ, "a")
+			if err = query.Update("views", 1); !errors.As(err, &validationErr) {
+				t.Errorf("Expected ValidationError updating a readonly field, got %v", err)
+			}
+			if _, err = query.build(); err != nil {
+				t.Errorf("Expected a failed update not to break the query, got %v", err)
+			}
+		},
+	)
+	t.Run(
+		"TestUpdateWriteRules", func(t *testing.T) {
+			t.Parallel()
+			profile := testProfile{Vertex: gsmtypes.Vertex{ID: 1}}
+			if err := Update(db, &profile); !errors.Is(err, ErrRequiredField) {
+				t.Errorf("Expected ErrRequiredField updating every field, got %v", err)
+			}
+			// the offline driver cannot write so the update fails after the write rules were applied
+			if err := Update(db, &profile, "status"); err == nil || errors.Is(err, ErrRequiredField) {
+				t.Errorf("Expected the unwritten required name not to be checked, got %v", err)
+			}
+			if profile.Status != "" || profile.Score != nil {
+				t.Errorf("Expected the value to be unchanged by a failed update, got %+v", profile)
+			}
+			draft := testProfile{Status: "active", Score: new(int)}
+			copyWrittenFields(reflect.ValueOf(&profile).Elem(), reflect.ValueOf(draft), []string{"status"})
+			if profile.Status != "active" || profile.Score != nil {
+				t.Errorf("Expected only the written status to be copied, got %+v", profile)
+			}
+		},
+	)
+	t.Run(
+		"TestLoadRules", func(t *testing.T) {
+			t.Parallel()
+			var profile testProfile
+			err := recursivelyUnloadIntoStruct(&profile, map[string]any{"name": "a", "views": int64(3), "secret": "s"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile.Views != 3 || profile.Secret != "" {
+				t.Errorf("Expected readonly fields to be loaded and writeonly fields not to be, got %+v", profile)
+			}
+		},
+	)
+	t.Run(
+		"TestInvalidTags", func(t *testing.T) {
+			t.Parallel()
+			err := schemaOf(reflect.TypeFor[testInvalidTags]()).tagsErr
+			if err == nil {
+				t.Fatal("Expected error for invalid tags")
+			}
+			for _, field := range []string{"Count", "Mode", "Kind", "Labels"} {
+				if !strings.Contains(err.Error(), "field "+field+" ") {
+					t.Errorf("Expected an error for %s, got %v", field, err)
+				}
+			}
+			if err = Create(db, &testInvalidTags{}); err == nil {
+				t.Error("Expected error creating a value with invalid tags")
+			}
+		},
+	)
+	t.Run(
+		"TestTagsAreCached", func(t *testing.T) {
+			t.Parallel()
+			first := schemaOf(reflect.TypeFor[testProfile]()).tags
+			second := schemaOf(reflect.TypeFor[testProfile]()).tags
+			if &first[0] != &second[0] {
+				t.Error("Expected the parsed tags of a type to be reused")
+			}
+		},
+	)
+}
+
+func TestTags(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+
+	profile := testProfile{Name: "a", Secret: "s"}
+	if err = Create(db, &profile); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-db.g.V(profile.ID).Property("views", 5).Iterate(); err != nil {
+		t.Fatal(err)
+	}
+	found, err := Model[testProfile](db).ID(profile.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Status != "active" || found.Views != 5 || found.Secret != "" || found.Score == nil {
+		t.Errorf("Expected defaults, the readonly views and no secret, got %+v", found)
+	}
+
+	found.Views = 0
+	found.Secret = "t"
+	if err = Update(db, &found); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := db.g.V(profile.ID).Values("secret").Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.GetString() != "t" {
+		t.Errorf("Expected the writeonly secret to be written, got %v", secret.GetString())
+	}
+	if updated, _ := Model[testProfile](db).ID(profile.ID); updated.Views != 5 {
+		t.Errorf("Expected the readonly views to be kept, got %d", updated.Views)
+	}
+}
@@ -0,0 +1,108 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+)
+
+// Tx is the transaction passed to the function given to GremlinDriver.Transaction
+type Tx struct {
+	db *GremlinDriver
+}
+
+// DB returns the driver scoped to the transaction, pass it to Create, Save, Model and the other package functions
+// the driver must not be used after the transaction function returned
+func (tx *Tx) DB() *GremlinDriver {
+	return tx.db
+}
+
+// transactionSupport remembers whether the graph behind a connection supports transactions
+// so the check is only sent once per connection
+type transactionSupport struct {
+	mu        sync.Mutex
+	checked   bool
+	supported bool
+}
+
+// Transaction runs fn in a transaction, the transaction is committed when fn returns nil
+// and rolled back when fn returns an error or panics, the panic is repanicked after the rollback
+// ErrTransactionsNotSupported is returned without calling fn when the graph does not support transactions
+func (driver *GremlinDriver) Transaction(fn func(tx *Tx) error) error {
+	if driver.inTransaction {
+		return ErrNestedTransaction
+	}
+	if err := driver.checkTransactionSupport(); err != nil {
+		return err
+	}
+	// the transaction is opened on the unbound source so the evaluationTimeout option is not shared with driver
+	transaction := driver.unboundSource().Tx()
+	txSource, err := transaction.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	txDriver := *driver
+	txDriver.bindSource(txSource)
+	txDriver.inTransaction = true
+
+	defer func() {
+		if r := recover(); r != nil {
+			if rollbackErr := transaction.Rollback(); rollbackErr != nil {
+				driver.logger.Errorf("Failed to roll back transaction after panic: %v", rollbackErr)
+			}
+			panic(r)
+		}
+	}()
+	if err = fn(&Tx{db: &txDriver}); err != nil {
+		if rollbackErr := transaction.Rollback(); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rollbackErr))
+		}
+		return err
+	}
+	if err = transaction.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// checkTransactionSupport opens and rolls back an empty transaction the first time it is called
+// the result is only remembered when the server answered, connection errors are returned as they are
+func (driver *GremlinDriver) checkTransactionSupport() error {
+	if driver.remoteConn == nil || driver.transactions == nil {
+		return fmt.Errorf("%w: the driver has no remote connection", ErrTransactionsNotSupported)
+	}
+	support := driver.transactions
+	support.mu.Lock()
+	defer support.mu.Unlock()
+	if !support.checked {
+		supported, err := probeTransactions(driver.g)
+		if err != nil {
+			return err
+		}
+		support.checked = true
+		support.supported = supported
+	}
+	if !support.supported {
+		return ErrTransactionsNotSupported
+	}
+	return nil
+}
+
+func probeTransactions(g *gremlingo.GraphTraversalSource) (bool, error) {
+	transaction := g.Tx()
+	if _, err := transaction.Begin(); err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	err := transaction.Rollback()
+	if err == nil {
+		return true, nil
+	}
+	// e.g. TinkerGraph answers "Graph does not support transactions"
+	if strings.Contains(strings.ToLower(err.Error()), "support transactions") {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check transaction support: %w", err)
+}
@@ -0,0 +1,66 @@
+package driver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransactionValidation(t *testing.T) {
+	t.Parallel()
+	t.Run(
+		"TestNoRemoteConnection", func(t *testing.T) {
+			t.Parallel()
+			called := false
+			err := newOfflineDriver().Transaction(func(_ *Tx) error {
+				called = true
+				return nil
+			})
+			if !errors.Is(err, ErrTransactionsNotSupported) {
+				t.Errorf("Expected ErrTransactionsNotSupported, got %v", err)
+			}
+			if called {
+				t.Error("Expected transaction function not to be called")
+			}
+		},
+	)
+	t.Run(
+		"TestNestedTransaction", func(t *testing.T) {
+			t.Parallel()
+			db := newOfflineDriver()
+			db.inTransaction = true
+			err := db.Transaction(func(_ *Tx) error {
+				return nil
+			})
+			if !errors.Is(err, ErrNestedTransaction) {
+				t.Errorf("Expected ErrNestedTransaction, got %v", err)
+			}
+		},
+	)
+}
+
+func TestTransaction(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Run(
+		"TestTinkerGraphNotSupported", func(t *testing.T) {
+			called := false
+			err = db.Transaction(func(tx *Tx) error {
+				called = true
+				return Create(tx.DB(), &testVertex{Name: "first"})
+			})
+			if !errors.Is(err, ErrTransactionsNotSupported) {
+				t.Errorf("Expected ErrTransactionsNotSupported, got %v", err)
+			}
+			if called {
+				t.Error("Expected transaction function not to be called")
+			}
+			// the result is remembered so the second call does not open a session
+			if err = db.Transaction(func(_ *Tx) error { return nil }); !errors.Is(err, ErrTransactionsNotSupported) {
+				t.Errorf("Expected ErrTransactionsNotSupported, got %v", err)
+			}
+		},
+	)
+}
@@ -0,0 +1,69 @@
+package driver
+
+import (
+	"os"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// Direction is the direction in which edges are followed when traversing between models
+type Direction int
+
+const (
+	Out Direction = iota
+	In
+	Both
+)
+
+func (d Direction) String() string {
+	switch d {
+	case In:
+		return "In"
+	case Both:
+		return "Both"
+	case Out:
+		return "Out"
+	}
+	return "Out"
+}
+
+// step appends the vertex step for the direction to the traversal
+func (d Direction) step(query *gremlingo.GraphTraversal, edgeLabel string) *gremlingo.GraphTraversal {
+	switch d {
+	case In:
+		return query.In(edgeLabel)
+	case Both:
+		return query.Both(edgeLabel)
+	case Out:
+		return query.Out(edgeLabel)
+	}
+	return query.Out(edgeLabel)
+}
+
+// Traverse follows the edges labeled edgeLabel in the given direction from every vertex matched by query
+// and returns a query for the related model U, conditions added to the returned query apply to U
+// Limit, Offset and OrderBy defined on query are applied before the hop
+func Traverse[T gsmtypes.VertexType, U gsmtypes.VertexType](
+	query *Query[T],
+	edgeLabel string,
+	direction Direction,
+) *Query[U] {
+	next := NewQuery[U](query.db)
+	if os.Getenv("GSM_DEBUG") == "true" {
+		next.debugString.Reset()
+		next.writeDebugString(query.debugString.String())
+		next.writeDebugString("." + direction.String() + "(" + edgeLabel + ")")
+		if next.label != "" {
+			next.writeDebugString(".HasLabel(" + next.label + ")")
+		}
+	}
+	next.start = func() (*gremlingo.GraphTraversal, error) {
+		// the source query is built with the driver of next so WithContext on next applies to the whole traversal
+		source := *query
+		source.db = next.db
+		start, err := source.buildTraversal()
+		return direction.step(start, edgeLabel), err
+	}
+	return next
+}
@@ -0,0 +1,111 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+)
+
+func TestTraverseBuild(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	tests := []struct {
+		testName string
+		query    *Query[testVertexForUtils]
+		expected string
+	}{
+		{
+			testName: "TestTraverseOut",
+			query: Traverse[testVertex, testVertexForUtils](
+				Model[testVertex](db).Where("name", comparator.EQ, "john"),
+				"member_of",
+				Out,
+			),
+			expected: "g.V().hasLabel('test_vertex').has('name','john')" +
+				".out('member_of').hasLabel('test_vertex_for_utils')",
+		},
+		{
+			testName: "TestTraverseInWithConditions",
+			query: Traverse[testVertex, testVertexForUtils](
+				Model[testVertex](db).IDs(1),
+				"member_of",
+				In,
+			).Where("sort", comparator.GT, 1).OrderBy("sort", Asc).Limit(2),
+			expected: "g.V(1).hasLabel('test_vertex')" +
+				".in('member_of').hasLabel('test_vertex_for_utils')" +
+				".has('sort',gt(1)).order().by('sort',asc).limit(2)",
+		},
+		{
+			testName: "TestTraverseBothWithIDs",
+			query: Traverse[testVertex, testVertexForUtils](
+				Model[testVertex](db).Limit(1),
+				"knows",
+				Both,
+			).IDs(2),
+			expected: "g.V().hasLabel('test_vertex').limit(1)" +
+				".both('knows').hasId(2).hasLabel('test_vertex_for_utils')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.testName, func(t *testing.T) {
+				t.Parallel()
+				if got := translate(t, tt.query.BuildQuery()); got != tt.expected {
+					t.Errorf("Expected %s, got %s", tt.expected, got)
+				}
+			},
+		)
+	}
+	t.Run(
+		"TestTraverseMultiHop", func(t *testing.T) {
+			t.Parallel()
+			users := Model[testVertex](db)
+			teams := Traverse[testVertex, testVertexForUtils](users, "member_of", Out)
+			back := Traverse[testVertexForUtils, testVertex](teams, "member_of", In).Dedup()
+			expected := "g.V().hasLabel('test_vertex')" +
+				".out('member_of').hasLabel('test_vertex_for_utils')" +
+				".in('member_of').hasLabel('test_vertex').dedup()"
+			if got := translate(t, back.BuildQuery()); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		},
+	)
+}
+
+func TestTraverse(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run(
+		"TestTraverseFind", func(t *testing.T) {
+			t.Cleanup(cleanDB)
+			user := testVertex{Name: "john"}
+			if err = Create(db, &user); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"first", "second"} {
+				team := testVertexForUtils{Name: name}
+				if err = Create(db, &team); err != nil {
+					t.Fatal(err)
+				}
+				if err = CreateEdge(db, &testEdge{Role: "member"}, user, team); err != nil {
+					t.Fatal(err)
+				}
+			}
+			teams, err := Traverse[testVertex, testVertexForUtils](
+				Model[testVertex](db).Where("name", comparator.EQ, "john"),
+				"test_edge",
+				Out,
+			).Where("name", comparator.EQ, "second").Find()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(teams) != 1 || teams[0].Name != "second" {
+				t.Errorf("Expected only the second team, got %v", teams)
+			}
+		},
+	)
+}
@@ -0,0 +1,325 @@
+package driver
+
+import (
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+// createdMarker is the property the merge of upsert writes on create only, see vertexMerge.createdTraversal
+const createdMarker = "_gsm_created"
+
+// keys of the result of vertexMerge.createdTraversal
+const (
+	upsertCreatedKey = "created"
+	upsertVertexKey  = "vertex"
+)
+
+// vertexMerge holds the maps of a single mergeV step, or the properties written to the vertex with id
+// or added with addV when search is nil
+type vertexMerge struct {
+	label string
+	// id is set when the existing vertex with the id and label is updated, nothing is written when it does not exist
+	id any
+	// guard is the optimistic locking check of the update of the vertex with id, see versionGuard
+	guard *versionGuard
+	// keys are the natural keys the update of the vertex with id must not share with another vertex
+	keys []string
+	// prefixes are the nested and map prefixes whose stored properties the update of the vertex with id replaces
+	prefixes []string
+	search   map[any]any
+	onCreate map[string]any
+	onMatch  map[string]any
+	// cleared holds the properties without a value, they are removed from a matched vertex
+	cleared []string
+	// multiValues are written after the merge, replacing the stored values of a matched vertex
+	multiValues []multiValue
+}
+
+// newVertexMerge builds the mergeV maps for a vertex, properties must not contain the id
+// a vertex with an id updates the stored vertex with the id and label, a vertex with natural keys is merged
+// on its label plus the keys and any other vertex is added so equal values are stored as separate vertices
+// created_at is only written when the vertex is created, last_modified on create and on match
+// the properties named in cardinalities are written per element after the merge, see multiValuedProperties
+func newVertexMerge(
+	id any,
+	label string,
+	properties map[string]any,
+	keys []string,
+	cardinalities map[string]any,
+	now time.Time,
+) (*vertexMerge, error) {
+	onMatch := maps.Clone(properties)
+	delete(onMatch, gsmtypes.CreatedAt)
+	onMatch[gsmtypes.LastModified] = now
+	for _, key := range keys {
+		if _, ok := cardinalities[key]; ok {
+			return nil, fmt.Errorf("natural key %s must be a single valued property", key)
+		}
+	}
+	multiValues, err := splitMultiValues(onMatch, cardinalities)
+	if err != nil {
+		return nil, err
+	}
+
+	if id != nil {
+		// nil values are kept, setProperties removes their stored property
+		return &vertexMerge{label: label, id: id, keys: keys, onMatch: onMatch, multiValues: multiValues}, nil
+	}
+
+	if len(keys) == 0 {
+		dropNilValues(onMatch)
+		onMatch[gsmtypes.CreatedAt] = now
+		return &vertexMerge{label: label, onMatch: onMatch, multiValues: multiValues}, nil
+	}
+
+	search := map[any]any{gremlingo.T.Label: label}
+	for _, key := range keys {
+		value, ok := onMatch[key]
+		if !ok {
+			return nil, fmt.Errorf("natural key %s is not a gremlin tag of %s", key, label)
+		}
+		// a zero key would merge every value which leaves the key empty into one vertex
+		if value == nil || reflect.ValueOf(value).IsZero() {
+			return nil, fmt.Errorf("natural key %s has no value", key)
+		}
+		search[key] = value
+		// the search map is inherited on create and matched on match so the keys are not repeated
+		delete(onMatch, key)
+	}
+	cleared := dropNilValues(onMatch)
+	onCreate := maps.Clone(onMatch)
+	onCreate[gsmtypes.CreatedAt] = now
+	return &vertexMerge{
+		search:      search,
+		onCreate:    onCreate,
+		onMatch:     onMatch,
+		cleared:     cleared,
+		multiValues: multiValues,
+	}, nil
+}
+
+// dropNilValues removes the properties without a value and returns their sorted names
+func dropNilValues(properties map[string]any) []string {
+	var dropped []string
+	for key, value := range properties {
+		if value == nil {
+			dropped = append(dropped, key)
+			delete(properties, key)
+		}
+	}
+	slices.Sort(dropped)
+	return dropped
+}
+
+// traversal appends the mergeV step with its options, the V step of an update of the vertex with id, written like
+// Update, or the addV step with the properties when search is nil, the step starts the traversal when query is nil
+func (m *vertexMerge) traversal(db *GremlinDriver, query *gremlingo.GraphTraversal) *gremlingo.GraphTraversal {
+	if m.id != nil {
+		if query == nil {
+			query = m.checked(db.g.V(m.id))
+		} else {
+			query = m.checked(query.V(m.id))
+		}
+		return writeUpdate(query, m.prefixes, m.onMatch, m.multiValues)
+	}
+	if m.search == nil {
+		if query == nil {
+			query = db.g.AddV(m.label)
+		} else {
+			query = query.AddV(m.label)
+		}
+		query = setProperties(query, m.onMatch)
+		for _, value := range m.multiValues {
+			query = value.write(query)
+		}
+		return query
+	}
+	if query == nil {
+		query = db.g.MergeV(m.search)
+	} else {
+		query = query.MergeV(m.search)
+	}
+	if m.onCreate != nil {
+		query = query.Option(gremlingo.Merge.OnCreate, m.onCreate)
+	}
+	query = query.Option(gremlingo.Merge.OnMatch, m.onMatch)
+	if len(m.cleared) > 0 {
+		keys := make([]any, len(m.cleared))
+		for i, key := range m.cleared {
+			keys[i] = key
+		}
+		query = query.SideEffect(anonymousTraversal.Properties(keys...).Drop())
+	}
+	for _, value := range m.multiValues {
+		query = value.write(query)
+	}
+	return query
+}
+
+// checked filters the vertex with id on its label and the version check of the guard
+func (m *vertexMerge) checked(query *gremlingo.GraphTraversal) *gremlingo.GraphTraversal {
+	query = query.HasLabel(m.label)
+	if m.guard != nil {
+		query = m.guard.apply(query, m.onMatch)
+	}
+	return query
+}
+
+// storedState returns an anonymous traversal yielding storedDuplicate when another vertex has one of the natural keys
+// of the update, storedFound when the vertex updated by the merge exists and passes the version check,
+// storedStale when it fails the check and storedMissing otherwise, see checkStored
+func (m *vertexMerge) storedState() *gremlingo.GraphTraversal {
+	states := make([]any, 0, 4)
+	if duplicate := duplicateKeyFilter(anonymousTraversal.V(), m.label, m.id, m.onMatch, m.keys); duplicate != nil {
+		states = append(states, duplicate.Constant(storedDuplicate))
+	}
+	states = append(states, m.checked(anonymousTraversal.V(m.id)).Constant(storedFound))
+	if m.guard != nil && m.guard.property != "" {
+		states = append(states, anonymousTraversal.V(m.id).HasLabel(m.label).Constant(storedStale))
+	}
+	states = append(states, anonymousTraversal.Constant(storedMissing))
+	return anonymousTraversal.Coalesce(states...)
+}
+
+// duplicateKeyFilter filters query on the vertices with label other than id which hold one of the natural keys
+// in properties, keys missing from properties or holding their zero value are not checked
+// nil is returned when there is no key to check
+func duplicateKeyFilter(
+	query *gremlingo.GraphTraversal,
+	label string,
+	id any,
+	properties map[string]any,
+	keys []string,
+) *gremlingo.GraphTraversal {
+	conditions := make([]any, 0, len(keys))
+	for _, key := range keys {
+		value, ok := properties[key]
+		if !ok || value == nil || reflect.ValueOf(value).IsZero() {
+			continue
+		}
+		conditions = append(conditions, anonymousTraversal.Has(key, value))
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+	return query.HasLabel(label).Or(conditions...).Not(anonymousTraversal.HasId(id)).Limit(1)
+}
+
+// checkUniqueKeys returns ErrDuplicateKey when the update of the vertex with id would give it
+// a natural key of another vertex, the check is read before the write so it does not guard against
+// a concurrent writer storing the same key in between
+func checkUniqueKeys(db *GremlinDriver, label string, id any, properties map[string]any, keys []string) error {
+	query := duplicateKeyFilter(db.g.V(), label, id, properties, keys)
+	if query == nil {
+		return nil
+	}
+	results, err := await(db, query.Id().ToList)
+	if err != nil {
+		return err
+	}
+	if len(results) > 0 {
+		return newDuplicateKeyError(label, id, keys)
+	}
+	return nil
+}
+
+func newDuplicateKeyError(label string, id any, keys []string) error {
+	return fmt.Errorf(
+		"%w: another %s vertex than %v has the same %s",
+		ErrDuplicateKey, label, id, strings.Join(keys, ", "),
+	)
+}
+
+// Upsert merges the value on its label plus its natural keys instead of its id
+// keys are gremlin tag names, when none are given the fields tagged unique are used, e.g. `gremlin:"email,unique"`
+// created_at is only set when no vertex matched, the id and properties of the stored vertex are loaded into value
+// BeforeSave is called before the merge, AfterCreate or AfterUpdate and AfterSave after it
+func Upsert[T gsmtypes.VertexType](db *GremlinDriver, value *T, keys ...string) error {
+	err := validateStructPointerWithAnonymousVertex(value)
+	if err != nil {
+		db.logger.Errorf("Validation failed: %v", err)
+		return err
+	}
+	if len(keys) == 0 {
+		keys = uniqueFieldNames(reflect.TypeFor[T]())
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf(
+			"%s has no natural keys, tag a field with %s or pass the keys",
+			reflect.TypeFor[T]().Name(), uniqueTagOption,
+		)
+	}
+	// whether the value is created or updated is only known afterwards so only BeforeSave is called before it
+	if err = callHook(value, gsmtypes.BeforeSaver.BeforeSave); err != nil {
+		return err
+	}
+	created, err := upsert(db, value, keys)
+	if err != nil {
+		return err
+	}
+	if created {
+		return afterCreate(value)
+	}
+	return afterUpdate(value)
+}
+
+// upsert merges the value on its label plus keys and reports whether the merge added a vertex
+func upsert[T gsmtypes.VertexType](db *GremlinDriver, value *T, keys []string) (bool, error) {
+	label, properties, err := structToMap(value)
+	if err != nil {
+		return false, err
+	}
+	delete(properties, "id")
+	cardinalities, err := schemaOf(reflect.TypeFor[T]()).cardinalities(db.dbDriver)
+	if err != nil {
+		return false, err
+	}
+	merge, err := newVertexMerge(nil, label, properties, keys, cardinalities, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	result, err := await(db, merge.createdTraversal(db, rand.Text()).Next)
+	if err != nil {
+		return false, err
+	}
+	projected, err := toStringMap(result.GetInterface())
+	if err != nil {
+		return false, err
+	}
+	stored, err := toStringMap(projected[upsertVertexKey])
+	if err != nil {
+		return false, err
+	}
+	if err = recursivelyUnloadIntoStruct(value, stored); err != nil {
+		return false, err
+	}
+	created, ok := projected[upsertCreatedKey].(bool)
+	if !ok {
+		return false, fmt.Errorf("unexpected upsert result %v", projected[upsertCreatedKey])
+	}
+	return created, nil
+}
+
+// createdTraversal merges the vertex and projects whether the merge added it next to the value map of the vertex
+// the onCreate map writes token to createdMarker, the marker is dropped again before the vertex is loaded
+// token must be unique per call so a concurrent merge of the same natural keys never reports the marker of another
+func (m *vertexMerge) createdTraversal(db *GremlinDriver, token string) *gremlingo.GraphTraversal {
+	merge := *m
+	merge.onCreate = maps.Clone(m.onCreate)
+	merge.onCreate[createdMarker] = token
+	return merge.traversal(db, nil).Project(upsertCreatedKey, upsertVertexKey).
+		By(anonymousTraversal.Choose(
+			anonymousTraversal.Has(createdMarker, token),
+			anonymousTraversal.SideEffect(anonymousTraversal.Properties(createdMarker).Drop()).Constant(true),
+			anonymousTraversal.Constant(false),
+		)).
+		By(ToMapTraversal(anonymousTraversal.Identity(), nil, true))
+}
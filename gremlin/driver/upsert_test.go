@@ -0,0 +1,232 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbrusegaard/graph-struct-manager/comparator"
+	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
+)
+
+type testUser struct {
+	gsmtypes.Vertex
+	Email string `json:"email" gremlin:"email,unique"`
+	Name  string `json:"name"  gremlin:"name"`
+}
+
+func TestUpsertValidation(t *testing.T) {
+	t.Parallel()
+	db := newOfflineDriver()
+	t.Run(
+		"TestParseGremlinTag", func(t *testing.T) {
+			t.Parallel()
+			field, _ := reflect.TypeFor[testUser]().FieldByName("Email")
+			tag, ok, err := parseGremlinTag(field)
+			if err != nil || !ok || tag.name != "email" || !tag.unique {
+				t.Errorf("Expected unique email tag, got %+v", tag)
+			}
+			field, _ = reflect.TypeFor[testVertexForUtils]().FieldByName("Ignore")
+			if _, ok, _ = parseGremlinTag(field); ok {
+				t.Error("Expected ignored field not to be mapped")
+			}
+			if names := uniqueFieldNames(reflect.TypeFor[testUser]()); !reflect.DeepEqual(names, []string{"email"}) {
+				t.Errorf("Expected unique fields [email], got %v", names)
+			}
+		},
+	)
+	t.Run(
+		"TestTagOptionsAreNotPartOfTheName", func(t *testing.T) {
+			t.Parallel()
+			_, mapValue, err := structToMap(&testUser{Email: "a@example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mapValue["email"] != "a@example.com" {
+				t.Errorf("Expected email property, got %v", mapValue)
+			}
+			query := Model[testUser](db).Where("email", comparator.EQ, "a@example.com")
+			if got := translate(t, query.BuildQuery()); got != "g.V().hasLabel('test_user').has('email','a@example.com')" {
+				t.Errorf("Unexpected query %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestNaturalKeyMerge", func(t *testing.T) {
+			t.Parallel()
+			now := time.Now().UTC()
+			merge, err := newVertexMerge(
+				nil,
+				"test_user",
+				map[string]any{"email": "a@example.com", "name": "A", gsmtypes.CreatedAt: time.Time{}},
+				[]string{"email"},
+				nil,
+				now,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(merge.search) != 2 || merge.search["email"] != "a@example.com" {
+				t.Errorf("Expected search on label and email, got %v", merge.search)
+			}
+			if merge.onCreate[gsmtypes.CreatedAt] != now || merge.onCreate["name"] != "A" {
+				t.Errorf("Expected created_at and name on create, got %v", merge.onCreate)
+			}
+			if _, ok := merge.onMatch[gsmtypes.CreatedAt]; ok {
+				t.Errorf("Expected created_at not to be written on match, got %v", merge.onMatch)
+			}
+			if merge.onMatch[gsmtypes.LastModified] != now {
+				t.Errorf("Expected last_modified on match, got %v", merge.onMatch)
+			}
+			got := translate(t, merge.traversal(db, nil))
+			if !strings.HasPrefix(got, "g.mergeV(") || !strings.Contains(got, ".option(onCreate,") ||
+				!strings.Contains(got, ".option(onMatch,") {
+				t.Errorf("Expected mergeV with onCreate and onMatch options, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestCreatedTraversal", func(t *testing.T) {
+			t.Parallel()
+			properties := map[string]any{"email": "a@example.com", "name": "A"}
+			merge, err := newVertexMerge(nil, "test_user", properties, []string{"email"}, nil, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := translate(t, merge.createdTraversal(db, "token"))
+			for _, step := range []string{
+				"'" + createdMarker + "':'token'",
+				".project('created','vertex').by(choose(has('" + createdMarker + "','token'),",
+				"sideEffect(properties('" + createdMarker + "').drop()).constant(true),constant(false))",
+			} {
+				if !strings.Contains(got, step) {
+					t.Errorf("Expected %s in %s", step, got)
+				}
+			}
+			if _, ok := merge.onCreate[createdMarker]; ok {
+				t.Errorf("Expected the marker not to be kept on the merge, got %v", merge.onCreate)
+			}
+		},
+	)
+	t.Run(
+		"TestDuplicateKeyFilter", func(t *testing.T) {
+			t.Parallel()
+			properties := map[string]any{"email": "a@example.com", "name": "A"}
+			query := duplicateKeyFilter(db.g.V(), "test_user", 1, properties, []string{"email"})
+			expected := "g.V().hasLabel('test_user').or(has('email','a@example.com')).not(hasId(1)).limit(1)"
+			if got := translate(t, query); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+			empty := map[string]any{"email": "", "name": "A"}
+			if query = duplicateKeyFilter(db.g.V(), "test_user", 1, empty, []string{"email"}); query != nil {
+				t.Errorf("Expected no check for an empty natural key, got %s", translate(t, query))
+			}
+			merge, err := newVertexMerge(1, "test_user", properties, []string{"email"}, nil, time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := translate(t, db.g.Inject(0).Map(merge.storedState())); !strings.Contains(got, storedDuplicate) {
+				t.Errorf("Expected the stored check to look for duplicate keys, got %s", got)
+			}
+		},
+	)
+	t.Run(
+		"TestInvalidNaturalKeys", func(t *testing.T) {
+			t.Parallel()
+			if err := Upsert(db, &testVertex{Name: "first"}); err == nil {
+				t.Error("Expected error for a type without natural keys")
+			}
+			if err := Upsert(db, &testUser{Email: "a@example.com"}, "mail"); err == nil {
+				t.Error("Expected error for an unknown natural key")
+			}
+			for _, email := range []any{nil, ""} {
+				properties := map[string]any{"email": email}
+				if _, err := newVertexMerge(nil, "test_user", properties, []string{"email"}, nil, time.Now()); err == nil {
+					t.Errorf("Expected error for a natural key without a value, got none for %#v", email)
+				}
+			}
+			if err := Upsert(db, &testUser{Name: "no email"}); err == nil {
+				t.Error("Expected error upserting a value with an empty natural key")
+			}
+		},
+	)
+}
+
+func TestUpsert(t *testing.T) {
+	db, err := Open(DbURL, Gremlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	t.Cleanup(cleanDB)
+	t.Run(
+		"TestUpsertMatchesNaturalKey", func(t *testing.T) {
+			first := testUser{Email: "a@example.com", Name: "first"}
+			if err = Upsert(db, &first); err != nil {
+				t.Fatal(err)
+			}
+			second := testUser{Email: "a@example.com", Name: "second"}
+			if err = Upsert(db, &second, "email"); err != nil {
+				t.Fatal(err)
+			}
+			if first.ID != second.ID {
+				t.Errorf("Expected the same vertex, got %v and %v", first.ID, second.ID)
+			}
+			if !second.CreatedAt.Equal(first.CreatedAt) {
+				t.Errorf("Expected created_at %v to be kept, got %v", first.CreatedAt, second.CreatedAt)
+			}
+			stored, findErr := Model[testUser](db).ID(first.ID)
+			if findErr != nil {
+				t.Fatal(findErr)
+			}
+			if stored.Name != "second" {
+				t.Errorf("Expected name to be updated, got %s", stored.Name)
+			}
+		},
+	)
+	t.Run(
+		"TestCreateUsesNaturalKey", func(t *testing.T) {
+			for range 2 {
+				if err = Create(db, &testUser{Email: "b@example.com", Name: "b"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			count, countErr := Model[testUser](db).Where("email", comparator.EQ, "b@example.com").Count()
+			if countErr != nil {
+				t.Fatal(countErr)
+			}
+			if count != 1 {
+				t.Errorf("Expected 1 vertex, got %d", count)
+			}
+		},
+	)
+	t.Run(
+		"TestUpdateKeepsNaturalKeysUnique", func(t *testing.T) {
+			taken := testUser{Email: "c@example.com", Name: "c"}
+			if err = Create(db, &taken); err != nil {
+				t.Fatal(err)
+			}
+			other := testUser{Email: "d@example.com", Name: "d"}
+			if err = Create(db, &other); err != nil {
+				t.Fatal(err)
+			}
+			other.Email = taken.Email
+			if err = Save(db, &other); !errors.Is(err, ErrDuplicateKey) {
+				t.Errorf("Expected ErrDuplicateKey from Save, got %v", err)
+			}
+			if err = Create(db, &other); !errors.Is(err, ErrDuplicateKey) {
+				t.Errorf("Expected ErrDuplicateKey from Create with an id, got %v", err)
+			}
+			err = SaveMany(db, []*testUser{&other})
+			if !errors.Is(err, ErrDuplicateKey) {
+				t.Errorf("Expected ErrDuplicateKey from SaveMany, got %v", err)
+			}
+			other.Name = "renamed"
+			if err = Update(db, &other, "name"); err != nil {
+				t.Errorf("Expected an update without the key to be written, got %v", err)
+			}
+		},
+	)
+}
@@ -3,11 +3,9 @@ package driver
 import (
 	"errors"
 	"fmt"
-	"maps"
 	"reflect"
 
 	gremlingo "github.com/apache/tinkerpop/gremlin-go/v3/driver"
-	"github.com/gobeam/stringy"
 	"github.com/jbrusegaard/graph-struct-manager/gsmtypes"
 )
 
@@ -23,8 +21,39 @@ type GremlinOrder int
 const (
 	Asc GremlinOrder = iota
 	Desc
+	Shuffle
 )
 
+func (o GremlinOrder) String() string {
+	switch o {
+	case Desc:
+		return "Order.Desc"
+	case Shuffle:
+		return "Order.Shuffle"
+	case Asc:
+		return "Order.Asc"
+	}
+	return "Order.Asc"
+}
+
+// gremlinOrder returns the gremlin order token for the order
+func (o GremlinOrder) gremlinOrder() any {
+	switch o {
+	case Desc:
+		return Order.Desc
+	case Shuffle:
+		return Order.Shuffle
+	case Asc:
+		return Order.Asc
+	}
+	return Order.Asc
+}
+
+// newAnonymousTraversal returns an empty anonymous traversal which steps can be appended to
+func newAnonymousTraversal() *gremlingo.GraphTraversal {
+	return gremlingo.NewGraphTraversal(nil, gremlingo.NewBytecode(nil), nil)
+}
+
 // getStructName takes a generic type T, confirms it's a struct, and returns its name
 func getStructName[T any]() (string, error) {
 	var s T
@@ -45,88 +74,100 @@ func UnloadGremlinResultIntoStruct(
 	v any,
 	result *gremlingo.Result,
 ) error {
-	mapResult, ok := result.GetInterface().(map[any]any)
+	stringMap, err := toStringMap(result.GetInterface())
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr {
+		return errors.New("v must be a pointer")
+	}
+	return recursivelyUnloadIntoStruct(v, stringMap)
+}
+
+// toStringMap converts a gremlin map result into a map keyed by strings
+func toStringMap(result any) (map[string]any, error) {
+	mapResult, ok := result.(map[any]any)
 	if !ok {
-		return errors.New("result is not a map")
+		return nil, errors.New("result is not a map")
 	}
-	// make string map
 	stringMap := make(map[string]any, len(mapResult))
 	for key, value := range mapResult {
 		keyStr, keyOk := key.(string)
 		if !keyOk {
-			return errors.New("gremlin key is not a string")
+			return nil, errors.New("gremlin key is not a string")
 		}
 		stringMap[keyStr] = value
 	}
-	rv := reflect.ValueOf(v)
-
-	if rv.Kind() != reflect.Ptr {
-		return errors.New("v must be a pointer")
-	}
-	recursivelyUnloadIntoStruct(v, stringMap)
-	return nil
+	return stringMap, nil
 }
 
-func recursivelyUnloadIntoStruct(v any, stringMap map[string]any) {
+func recursivelyUnloadIntoStruct(v any, stringMap map[string]any) error {
 	rv := reflect.ValueOf(v).Elem()
-	rt := rv.Type()
-
-	for i := range rv.NumField() {
-		field := rv.Field(i)
-		fieldType := rt.Field(i)
-		// handle anonymous Vertex field
-		if fieldType.Anonymous {
-			recursivelyUnloadIntoStruct(field.Addr().Interface(), stringMap)
-		}
+	schema := schemaOf(rv.Type())
+	if schema.err != nil {
+		return schema.err
+	}
 
-		gremlinTag := rt.Field(i).Tag.Get(gsmtypes.GremlinTag)
-		gremlinSubTraversalTag := rt.Field(i).Tag.Get(gsmtypes.GremlinSubTraversalTag)
-		if !field.CanInterface() || !field.CanSet() || ((gremlinTag == "" || gremlinTag == "-") &&
-			(gremlinSubTraversalTag == "" || gremlinSubTraversalTag == "-")) {
+	// preloaded edges are projected under the field name
+	for _, edge := range schema.edges {
+		preloaded, ok := stringMap[edge.name]
+		if !ok {
 			continue
 		}
-		_, gremlinTagOk := stringMap[gremlinTag]
-		_, subtraversalTagOk := stringMap[gremlinSubTraversalTag]
-		if !gremlinTagOk && !subtraversalTagOk {
+		field := rv.FieldByIndex(edge.index)
+		if !field.CanSet() {
 			continue
 		}
-		if subtraversalTagOk {
-			gremlinTag = gremlinSubTraversalTag
+		if err := unloadPreloadedField(field, preloaded); err != nil {
+			return fmt.Errorf("error unloading preloaded field %s: %w", edge.name, err)
 		}
-		gType := reflect.TypeOf(stringMap[gremlinTag])
+	}
 
-		switch {
-		case gType.ConvertibleTo(field.Type()):
-			field.Set(reflect.ValueOf(stringMap[gremlinTag]).Convert(field.Type()))
-		case gType.Kind() == reflect.Slice:
-			strSlice := stringMap[gremlinTag].([]any) //nolint:errcheck // we already validated via reflect type check
-			slice := reflect.MakeSlice(
-				field.Type(), len(strSlice), len(strSlice),
-			)
-			for i, v := range strSlice {
-				slice.Index(i).Set(reflect.ValueOf(v).Convert(field.Type().Elem()))
+	for _, property := range schema.properties {
+		field := rv.FieldByIndex(property.index)
+		if !field.CanSet() {
+			continue
+		}
+		key := property.tag.name
+		if property.tag.writeOnly {
+			key = ""
+		}
+		if _, ok := stringMap[property.subTraversal]; ok && property.subTraversal != "" {
+			key = property.subTraversal
+		} else if key == "" {
+			continue
+		} else if property.encoding != encodePlain && property.encoding != encodeConverted {
+			if _, err := unloadNestedField(field, property.tag, stringMap); err != nil {
+				return err
 			}
-			field.Set(slice)
-		case field.Type().Kind() == reflect.Slice && gType.ConvertibleTo(field.Type().Elem()):
-			// Handle case where field is a slice but gremlin result is a single value
-			// Create a slice with one element
-			slice := reflect.MakeSlice(field.Type(), 1, 1)
-			slice.Index(0).Set(reflect.ValueOf(stringMap[gremlinTag]).Convert(field.Type().Elem()))
-			field.Set(slice)
+			continue
+		}
+		value, ok := stringMap[key]
+		if !ok {
+			continue
+		}
+		// plain values loaded with the type of the field are set without converting them
+		if property.encoding == encodePlain && reflect.TypeOf(value) == field.Type() {
+			field.Set(reflect.ValueOf(value))
+			continue
+		}
+		if err := assignValue(field, value); err != nil {
+			return fmt.Errorf("error unloading property %s into field %s: %w", key, property.name, err)
+		}
+		if property.tag.cardinality == setTagOption {
+			dedupeSlice(field)
 		}
 	}
+	return nil
 }
 
 func getLabelFromVertex(value gsmtypes.VertexType) string {
 	label := value.Label()
 	if label == "" {
-		// Get the concrete type from the interface
-		concreteType := reflect.ValueOf(value).Type()
-		// Handle pointer types
-		if concreteType.Kind() == reflect.Ptr {
-			concreteType = concreteType.Elem()
-		}
-		return stringy.New(concreteType.Name()).SnakeCase().ToLower()
+		// Get the concrete type from the interface, pointer types are handled by schemaOf
+		return schemaOf(reflect.ValueOf(value).Type()).label
 	}
 	return label
 }
@@ -134,13 +175,8 @@ func getLabelFromVertex(value gsmtypes.VertexType) string {
 func getLabelFromEdge(value gsmtypes.EdgeType) string {
 	label := value.Label()
 	if label == "" {
-		// Get the concrete type from the interface
-		concreteType := reflect.ValueOf(value).Type()
-		// Handle pointer types
-		if concreteType.Kind() == reflect.Ptr {
-			concreteType = concreteType.Elem()
-		}
-		return stringy.New(concreteType.Name()).SnakeCase().ToLower()
+		// Get the concrete type from the interface, pointer types are handled by schemaOf
+		return schemaOf(reflect.ValueOf(value).Type()).label
 	}
 	return label
 }
@@ -150,9 +186,11 @@ func getLabelFromEdge(value gsmtypes.EdgeType) string {
 // the map is the map of the struct
 // the error is the error if any
 func structToMap(value any) (string, map[string]any, error) {
-	mapValue := make(map[string]any)
-	var err error
+	return structProperties(value, nil)
+}
 
+// structProperties is structToMap, zero required fields are added to missing instead of rejected when it is not nil
+func structProperties(value any, missing *[]string) (string, map[string]any, error) {
 	// Get the reflection value
 	rv := reflect.ValueOf(value)
 
@@ -164,8 +202,6 @@ func structToMap(value any) (string, map[string]any, error) {
 	if rv.Kind() != reflect.Struct {
 		return "", nil, errors.New("value is not a struct")
 	}
-	// Get the type information
-	rt := rv.Type()
 
 	// Get the label using the helper function
 	var label string
@@ -177,49 +213,87 @@ func structToMap(value any) (string, map[string]any, error) {
 		return "", nil, errors.New("value must implement either VertexType or EdgeType")
 	}
 
-	// Loop through all fields
-	for i := range rv.NumField() {
-		field := rt.Field(i)
-		fieldValue := rv.Field(i)
-
-		if field.Anonymous && fieldValue.Kind() == reflect.Struct {
-			// Recursively process the anonymous struct
-			_, anonymousMap, structMapErr := structToMap(fieldValue.Interface())
-			if structMapErr != nil {
-				return "", nil, fmt.Errorf(
-					"error processing anonymous field %s: %w",
-					field.Name,
-					err,
-				)
-			}
-			maps.Copy(mapValue, anonymousMap)
-			continue
-		}
+	mapValue := make(map[string]any)
+	if err := addStructProperties(rv, "", mapValue, missing); err != nil {
+		return "", nil, err
+	}
+	// deleted_at is only written by Delete and Restore so a zero value never marks a vertex as deleted
+	if embedsSoftDelete(rv.Type()) {
+		delete(mapValue, gsmtypes.DeletedAt)
+	}
+	return label, mapValue, nil
+}
 
+// addStructProperties adds the gremlin tagged fields of rv and of its embedded structs to properties
+// the property names are prefixed with prefix, nested structs and maps are flattened, see addFieldProperty
+// readonly fields are skipped and zero fields are set to their default, rejected when required
+// and skipped when tagged omitempty, zero required fields are added to missing instead when it is not nil
+func addStructProperties(rv reflect.Value, prefix string, properties map[string]any, missing *[]string) error {
+	schema := schemaOf(rv.Type())
+	if schema.err != nil {
+		return schema.err
+	}
+	for _, property := range schema.properties {
 		// Skip sub traversal tags so this doesnt get included when creating vertices
-		if field.Tag.Get(gsmtypes.GremlinSubTraversalTag) != "" {
+		// and skip fields which are only loaded
+		if property.subTraversal != "" || property.tag.readOnly {
 			continue
 		}
-
-		// Get the gremlin tag
-		gremlinTag := field.Tag.Get(gsmtypes.GremlinTag)
-
-		// Skip if no gremlin tag or if field is not exported
-		if gremlinTag == "" || gremlinTag == "-" || !fieldValue.CanInterface() {
+		fieldValue := rv.FieldByIndex(property.index)
+		if !fieldValue.CanInterface() {
 			continue
 		}
-
-		// Get the field value
-		fieldInterface := fieldValue.Interface()
+		tag := property.tag
+		if fieldValue.IsZero() {
+			fieldValue = defaultFieldValue(fieldValue, tag)
+		}
+		if fieldValue.IsZero() {
+			if tag.required && missing == nil {
+				return fmt.Errorf("%w: %s", ErrRequiredField, prefix+tag.name)
+			}
+			if tag.required {
+				*missing = append(*missing, prefix+tag.name)
+			}
+			if tag.omitEmpty {
+				continue
+			}
+		}
 
 		// Use the gremlin tag as the property name
-		mapValue[gremlinTag] = fieldInterface
+		if property.encoding == encodePlain {
+			properties[prefix+tag.name] = fieldValue.Interface()
+			continue
+		}
+		if err := addFieldProperty(prefix+tag.name, tag, fieldValue, properties, missing); err != nil {
+			return err
+		}
 	}
-
-	return label, mapValue, nil
+	return nil
 }
 
 func validateStructPointerWithAnonymousVertex(value any) error {
+	return validateStructPointerWithAnonymousField(
+		value,
+		reflect.TypeFor[gsmtypes.Vertex](),
+		"struct must contain anonymous types.Vertex field",
+	)
+}
+
+func validateStructPointerWithAnonymousEdge(value any) error {
+	return validateStructPointerWithAnonymousField(
+		value,
+		reflect.TypeFor[gsmtypes.Edge](),
+		"struct must contain anonymous types.Edge field",
+	)
+}
+
+// validateStructPointerWithAnonymousField checks that value is a non nil pointer to a struct
+// which embeds anonymousType, missingErr is returned when the embedded field cannot be found
+func validateStructPointerWithAnonymousField(
+	value any,
+	anonymousType reflect.Type,
+	missingErr string,
+) error {
 	rv := reflect.ValueOf(value)
 
 	// Check if it's a pointer
@@ -237,29 +311,78 @@ func validateStructPointerWithAnonymousVertex(value any) error {
 		return errors.New("value must point to a struct")
 	}
 
-	// Get the struct type
-	rt := rv.Elem().Type()
+	// Check for the anonymous field
+	if schemaOf(rv.Type()).embedded[anonymousType] {
+		return nil
+	}
 
-	// Check for anonymous Vertex field
-	for i := range rv.Elem().NumField() {
-		field := rt.Field(i)
+	return errors.New(missingErr)
+}
 
-		if field.Anonymous && field.Type == reflect.TypeFor[gsmtypes.Vertex]() {
-			return nil
-		}
+func getStructFieldNameAndType[T any](tag string) (string, reflect.Type, error) {
+	rt := reflect.TypeFor[T]()
+	if field, ok := schemaOf(rt).property(tag); ok {
+		return field.name, rt.FieldByIndex(field.index).Type, nil
 	}
+	return "", nil, errors.New("field not found")
+}
 
-	return errors.New("struct must contain anonymous types.Vertex field")
+// gremlinFieldNames returns the gremlin tag names of the struct type including the ones of embedded structs
+// the properties of nested structs are included with their prefix, map fields with a wildcard key, e.g. meta.*
+// the returned set is shared by every caller and must not be modified
+func gremlinFieldNames(rt reflect.Type) map[string]struct{} {
+	schema := schemaOf(rt)
+	schema.fieldNamesOnce.Do(func() {
+		addGremlinFieldNames(schema.rt, "", schema.fieldNames, map[reflect.Type]bool{})
+	})
+	return schema.fieldNames
 }
 
-func getStructFieldNameAndType[T any](tag string) (string, reflect.Type, error) {
-	var s T
-	rt := reflect.TypeOf(s)
+func addGremlinFieldNames(rt reflect.Type, prefix string, names map[string]struct{}, visiting map[reflect.Type]bool) {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	// a struct nesting itself through a pointer is only expanded once
+	if rt.Kind() != reflect.Struct || visiting[rt] {
+		return
+	}
+	visiting[rt] = true
+	defer delete(visiting, rt)
+	tags := schemaOf(rt).tags
 	for i := range rt.NumField() {
 		field := rt.Field(i)
-		if field.Tag.Get(gsmtypes.GremlinTag) == tag {
-			return field.Name, field.Type, nil
+		if field.Anonymous {
+			addGremlinFieldNames(field.Type, prefix, names, visiting)
+			continue
+		}
+		tag := tags[i]
+		if tag.name == "" {
+			continue
+		}
+		name := prefix + tag.name
+		names[name] = struct{}{}
+		switch {
+		case tag.json:
+		case isNestedStruct(field.Type):
+			addGremlinFieldNames(field.Type, name+nestedFieldSeparator, names, visiting)
+		case isStringMap(field.Type):
+			names[name+nestedFieldSeparator+mapKeyWildcard] = struct{}{}
 		}
 	}
-	return "", nil, errors.New("field not found")
+}
+
+// toAnySlice converts a slice or array of any element type into []any
+func toAnySlice(value any) ([]any, bool) {
+	if values, ok := value.([]any); ok {
+		return values, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	values := make([]any, rv.Len())
+	for i := range rv.Len() {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
 }
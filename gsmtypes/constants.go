@@ -3,4 +3,5 @@ package gsmtypes
 const (
 	GremlinTag             = "gremlin"
 	GremlinSubTraversalTag = "gremlinSubTraversal"
+	GremlinEdgeTag         = "gremlinEdge"
 )
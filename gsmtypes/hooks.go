@@ -0,0 +1,51 @@
+package gsmtypes
+
+// Models opt into lifecycle hooks by implementing the interfaces below on their pointer type.
+// An error returned by a Before hook aborts the operation before anything is sent to the server,
+// an error returned by an After hook is returned by the operation after it was applied.
+
+// BeforeSaver is called before a vertex is created or updated, before BeforeCreate and BeforeUpdate
+type BeforeSaver interface {
+	BeforeSave() error
+}
+
+// AfterSaver is called after a vertex was created or updated, after AfterCreate and AfterUpdate
+type AfterSaver interface {
+	AfterSave() error
+}
+
+// BeforeCreator is called before a vertex is created
+// merges on natural keys only call BeforeSave, whether they create the vertex is only known afterwards
+type BeforeCreator interface {
+	BeforeCreate() error
+}
+
+// AfterCreator is called after a vertex was created, the id and timestamps are already set
+type AfterCreator interface {
+	AfterCreate() error
+}
+
+// BeforeUpdater is called before an existing vertex is updated, merges on natural keys only call BeforeSave
+type BeforeUpdater interface {
+	BeforeUpdate() error
+}
+
+// AfterUpdater is called after an existing vertex was updated
+type AfterUpdater interface {
+	AfterUpdate() error
+}
+
+// AfterFinder is called for every vertex loaded by Find, Take and ID
+type AfterFinder interface {
+	AfterFind() error
+}
+
+// BeforeDeleter is called for every vertex matched by a delete, the vertices are loaded first
+type BeforeDeleter interface {
+	BeforeDelete() error
+}
+
+// AfterDeleter is called for every vertex removed by a delete
+type AfterDeleter interface {
+	AfterDelete() error
+}
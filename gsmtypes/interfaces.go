@@ -15,3 +15,15 @@ type EdgeType interface {
 	GetEdgeCreatedAt() int64
 	Label() string
 }
+
+// GremlinMarshaler is implemented by field types which choose the value stored in their gremlin property
+// returning nil leaves the property unset
+type GremlinMarshaler interface {
+	MarshalGremlin() (any, error)
+}
+
+// GremlinUnmarshaler is implemented by field types which load themselves from the value of their gremlin property
+// value is nil when the stored value is null, the method is not called when the property is missing
+type GremlinUnmarshaler interface {
+	UnmarshalGremlin(value any) error
+}
@@ -5,6 +5,7 @@ import "time"
 const (
 	LastModified = "last_modified"
 	CreatedAt    = "created_at"
+	DeletedAt    = "deleted_at"
 )
 
 type Vertex struct {
@@ -22,6 +23,15 @@ func (v Vertex) Label() string {
 	return ""
 }
 
+// SoftDelete is embedded next to Vertex to make Delete set deleted_at instead of dropping the vertex
+// queries skip vertices with a deleted_at property unless they are Unscoped
+type SoftDelete struct {
+	DeletedAt time.Time `json:"deleted_at" gremlin:"deleted_at"`
+}
+
+// IsDeleted reports whether the vertex was soft deleted
+func (s SoftDelete) IsDeleted() bool { return !s.DeletedAt.IsZero() }
+
 type Edge struct {
 	ID           any    `json:"id"            gremlin:"id"`
 	LastModified string `json:"last_modified" gremlin:"last_modified"`